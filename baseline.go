@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/marcsantiago/search_keyword/search"
+)
+
+// readBaseline reads the output of an earlier run written in format so a new run can be compared against it. Only
+// found is read back, not the match count. The keyword is set on every result to pair them
+// up with the new run's
+func readBaseline(path, format, keyword string) (search.Results, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var results search.Results
+	br := bufio.NewReader(f)
+	switch format {
+	case formatURLs:
+		s := bufio.NewScanner(br)
+		for s.Scan() {
+			if URL := strings.TrimSpace(s.Text()); URL != "" {
+				results = append(results, search.Result{URL: URL, Keyword: keyword, Found: true})
+			}
+		}
+		return results, s.Err()
+	case formatCSV:
+		// skip the title line, it isn't csv
+		if _, err := br.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		r := csv.NewReader(br)
+		r.FieldsPerRecord = -1
+		for {
+			rec, err := r.Read()
+			if err == io.EOF {
+				return results, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			if len(rec) < 2 || rec[0] == "url" {
+				continue
+			}
+			found, _ := strconv.ParseBool(rec[1])
+			results = append(results, search.Result{URL: rec[0], Keyword: keyword, Found: found})
+		}
+	}
+	return nil, fmt.Errorf("unknown output format %q", format)
+}
+
+// writeDiff writes the urls that started or stopped matching since the baseline, as url,change rows for csv where
+// change is matched or unmatched, or just the urls one per line. Baselines read back from the output don't carry match
+// counts so Diff.Changed is left out
+func writeDiff(w io.Writer, d search.Diff, format string) error {
+	bw := bufio.NewWriter(w)
+	switch format {
+	case formatURLs:
+		for _, r := range append(d.Matched, d.Unmatched...) {
+			fmt.Fprintln(bw, r.URL)
+		}
+	default:
+		cw := csv.NewWriter(bw)
+		cw.Write([]string{"url", "change"})
+		for _, r := range d.Matched {
+			cw.Write([]string{r.URL, "matched"})
+		}
+		for _, r := range d.Unmatched {
+			cw.Write([]string{r.URL, "unmatched"})
+		}
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"sync"
+)
+
+// checkpoint records every input url once it has been searched so an interrupted run can be resumed from where it
+// stopped rather than from the start. Each line of the state file is a url, followed by a tab and the id when the
+// input line had one
+type checkpoint struct {
+	mu   sync.Mutex
+	f    *os.File
+	buf  *bufio.Writer
+	done map[string]bool
+	rows int
+	// before is called ahead of every flush so the output holds the results of every url the state says is done
+	before func() error
+}
+
+// openCheckpoint opens the state file at path. With resume the urls already in it are skipped and new ones appended,
+// otherwise it is started afresh
+func openCheckpoint(path string, resume bool) (*checkpoint, error) {
+	c := &checkpoint{done: make(map[string]bool)}
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if resume {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		if err := c.load(path); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	f, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return nil, err
+	}
+	c.f, c.buf = f, bufio.NewWriter(f)
+	return c, nil
+}
+
+func (c *checkpoint) load(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		// a line cut short by a crash mid write is searched again
+		if line := strings.TrimSpace(s.Text()); line != "" {
+			c.done[line] = true
+		}
+	}
+	return s.Err()
+}
+
+func checkpointKey(URL, id string) string {
+	if id == "" {
+		return URL
+	}
+	return URL + "\t" + id
+}
+
+// len is how many urls were already done when the checkpoint was opened, plus the ones marked since
+func (c *checkpoint) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.done)
+}
+
+// wrap skips the urls that are already done and marks the others once they have been searched, failed or not
+func (c *checkpoint) wrap(next searchFunc) searchFunc {
+	return func(URL, id string) error {
+		key := checkpointKey(URL, id)
+		c.mu.Lock()
+		skip := c.done[key]
+		c.mu.Unlock()
+		if skip {
+			return nil
+		}
+
+		err := next(URL, id)
+		c.mark(key)
+		return err
+	}
+}
+
+func (c *checkpoint) mark(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.done[key] = true
+	c.buf.WriteString(key + "\n")
+	c.rows++
+	if c.rows%flushEvery == 0 {
+		c.flushLocked()
+	}
+}
+
+// flush writes the output then the state to disk
+func (c *checkpoint) flush() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.flushLocked()
+}
+
+func (c *checkpoint) flushLocked() error {
+	if c.before != nil {
+		if err := c.before(); err != nil {
+			return err
+		}
+	}
+	if err := c.buf.Flush(); err != nil {
+		return err
+	}
+	return c.f.Sync()
+}
+
+// close flushes and closes the state file
+func (c *checkpoint) close() error {
+	err := c.flush()
+	if cerr := c.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/marcsantiago/search_keyword/search"
+)
+
+// config is a -config file in TOML. Its top level keys are flag names taking the same values, e.g. concurrency = 50
+// or timeout = "30s", and lists such as proxies can be arrays. Headers and auth apply to every request, each domain
+// can have its own rate, headers and auth:
+//
+//	keyword = "sign up"
+//	[headers]
+//	Accept-Language = "en"
+//	[domains."example.com"]
+//	rate = 0.5
+//	headers = { Accept-Language = "fr" }
+//	auth = { token = "..." }
+//
+// Flags given on the command line win over the file
+type config struct {
+	flags   map[string]interface{}
+	Headers map[string]string       `toml:"headers"`
+	Auth    authConfig              `toml:"auth"`
+	Domains map[string]domainConfig `toml:"domains"`
+}
+
+// domainConfig are the settings for one host, or host:port
+type domainConfig struct {
+	// Rate is the most requests per second sent to the host, like -host-rate
+	Rate    *float64          `toml:"rate"`
+	Headers map[string]string `toml:"headers"`
+	Auth    authConfig        `toml:"auth"`
+}
+
+// authConfig is basic auth or, when token is set, a bearer token. They are kept out of flags so they don't show up
+// in the process list
+type authConfig struct {
+	Username string `toml:"username"`
+	Password string `toml:"password"`
+	Token    string `toml:"token"`
+}
+
+func (a authConfig) credentials() search.Credentials {
+	return search.Credentials{Username: a.Username, Password: a.Password, Token: a.Token}
+}
+
+// loadConfig reads the config file at path
+func loadConfig(path string) (*config, error) {
+	c := &config{}
+	if _, err := toml.DecodeFile(path, c); err != nil {
+		return nil, err
+	}
+	if _, err := toml.DecodeFile(path, &c.flags); err != nil {
+		return nil, err
+	}
+	for _, k := range []string{"headers", "auth", "domains"} {
+		delete(c.flags, k)
+	}
+	return c, nil
+}
+
+// setFlags sets the flags the file holds unless they were given on the command line, a key that isn't a flag is an
+// error
+func (c *config) setFlags(fs *flag.FlagSet) error {
+	given := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+
+	for name, v := range c.flags {
+		if fs.Lookup(name) == nil || name == "config" {
+			return fmt.Errorf("unknown config key %q", name)
+		}
+		if given[name] {
+			continue
+		}
+
+		value := fmt.Sprint(v)
+		if list, ok := v.([]interface{}); ok {
+			parts := make([]string, len(list))
+			for i, p := range list {
+				parts[i] = fmt.Sprint(p)
+			}
+			value = strings.Join(parts, ",")
+		}
+		if err := fs.Set(name, value); err != nil {
+			return fmt.Errorf("config key %q: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// apply sets the headers, auth and per domain settings on the scanner
+func (c *config) apply(sc *search.Scanner) {
+	if len(c.Headers) > 0 {
+		sc.Header = toHeader(c.Headers)
+	}
+	sc.Auth = c.Auth.credentials()
+	for host, d := range c.Domains {
+		if d.Rate != nil {
+			if sc.HostRates == nil {
+				sc.HostRates = make(map[string]float64)
+			}
+			sc.HostRates[host] = *d.Rate
+		}
+		if len(d.Headers) > 0 {
+			if sc.HostHeader == nil {
+				sc.HostHeader = make(map[string]http.Header)
+			}
+			sc.HostHeader[host] = toHeader(d.Headers)
+		}
+		if creds := d.Auth.credentials(); creds != (search.Credentials{}) {
+			if sc.HostAuth == nil {
+				sc.HostAuth = make(map[string]search.Credentials)
+			}
+			sc.HostAuth[host] = creds
+		}
+	}
+}
+
+func toHeader(m map[string]string) http.Header {
+	h := make(http.Header, len(m))
+	for k, v := range m {
+		h.Set(k, v)
+	}
+	return h
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// sniffLen is how much of a file is looked at to decide whether it is binary
+const sniffLen = 512
+
+// errBinaryFile is returned for files that don't look like a text list of urls
+var errBinaryFile = errors.New("file looks binary")
+
+// eachLine calls fn with every line of the url list at path, .gz files and .tar.gz archives are decompressed on the fly
+func eachLine(path, format string, fn func(line string)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	switch {
+	case strings.HasSuffix(path, ".tar.gz"), strings.HasSuffix(path, ".tgz"):
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+
+		tr := tar.NewReader(gz)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			// only plain files, and like the directory mode skip .DS_Store and friends
+			if hdr.Typeflag != tar.TypeReg || strings.HasPrefix(baseName(hdr.Name), ".") {
+				continue
+			}
+			if err := scanLines(tr, format, fn); err != nil && err != errBinaryFile {
+				return err
+			}
+		}
+	case strings.HasSuffix(path, ".gz"):
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		return scanLines(gz, format, fn)
+	}
+	return scanLines(file, format, fn)
+}
+
+// scanLines calls fn with every line of r, errBinaryFile is returned without reading any lines when r isn't text. When
+// format is json and r is a json array fn is called with each element instead, as a line of compact json
+func scanLines(r io.Reader, format string, fn func(line string)) error {
+	br := bufio.NewReader(r)
+	head, err := br.Peek(sniffLen)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return err
+	}
+	if isBinary(head) {
+		return errBinaryFile
+	}
+	if trimmed := bytes.TrimLeft(head, " \t\r\n\ufeff"); format == inputJSON && bytes.HasPrefix(trimmed, []byte("[")) {
+		// the json decoder chokes on a byte order mark
+		br.Discard(len(head) - len(trimmed))
+		return scanJSONArray(br, fn)
+	}
+
+	scanner := bufio.NewScanner(br)
+	for scanner.Scan() {
+		fn(scanner.Text())
+	}
+	return scanner.Err()
+}
+
+// scanJSONArray calls fn with every element of the json array in r, an export can be too big to decode at once so
+// the elements are read one at a time
+func scanJSONArray(r io.Reader, fn func(line string)) error {
+	dec := json.NewDecoder(r)
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	for dec.More() {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return err
+		}
+		buf.Reset()
+		if err := json.Compact(&buf, raw); err != nil {
+			return err
+		}
+		fn(buf.String())
+	}
+	_, err := dec.Token()
+	return err
+}
+
+// isBinary guesses whether b is the start of a binary file, text lists never hold NUL bytes and are valid utf8
+func isBinary(b []byte) bool {
+	for _, c := range b {
+		if c == 0 {
+			return true
+		}
+	}
+
+	// the peek may have cut a multi byte rune in half
+	for i := 0; i < utf8.UTFMax && len(b) > 0; i++ {
+		if utf8.Valid(b) {
+			return false
+		}
+		b = b[:len(b)-1]
+	}
+	return !utf8.Valid(b)
+}
+
+func baseName(name string) string {
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+const (
+	// inputCSV reads the url from a column of a csv line, by default the first that holds a url
+	inputCSV = "csv"
+	// inputPlain reads one bare url per line
+	inputPlain = "plain"
+	// inputJSONL reads {"url": "...", "id": "..."} objects, one per line
+	inputJSONL = "jsonl"
+	// inputJSON reads a json array of urls or of objects like the jsonl ones, newline delimited objects work too
+	inputJSON = "json"
+)
+
+// lineParser pulls the url and an optional id out of an input line, ok is false for lines that should be skipped.
+// Lines are parsed in the order they are read
+type lineParser func(line string) (URL, id string, ok bool)
+
+// parserFor returns the parser of the input format, urlColumn picks the column of csv input or the field of json
+// objects holding the url and is an error for plain input
+func parserFor(format, urlColumn string) (lineParser, error) {
+	if urlColumn != "" && format == inputPlain {
+		return nil, fmt.Errorf("a url column doesn't apply to %s input", inputPlain)
+	}
+	switch format {
+	case inputCSV:
+		return newCSVParser(urlColumn)
+	case inputPlain:
+		return parsePlainLine, nil
+	case inputJSONL, inputJSON:
+		if urlColumn == "" {
+			return parseJSONLine, nil
+		}
+		return func(line string) (string, string, bool) {
+			return parseJSON(line, urlColumn)
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown input format %q", format)
+}
+
+// newCSVParser returns a parser reading the url from column, which is a 1 based column number or the name of the
+// column in the header row. Left empty the url is the first field that looks like one. Either way a header row is
+// skipped since none of its fields look like urls
+func newCSVParser(column string) (lineParser, error) {
+	if column == "" {
+		return parseCSVLine, nil
+	}
+
+	if n, err := strconv.Atoi(column); err == nil {
+		if n < 1 {
+			return nil, fmt.Errorf("url column %d out of range, columns start at 1", n)
+		}
+		return func(line string) (string, string, bool) {
+			fields := csvFields(line)
+			if len(fields) < n || !looksLikeURL(fields[n-1]) {
+				return "", "", false
+			}
+			return fields[n-1], "", true
+		}, nil
+	}
+
+	// the column is found in the header row, each file of a directory can have its own
+	index := -1
+	return func(line string) (string, string, bool) {
+		fields := csvFields(line)
+		for i, f := range fields {
+			if strings.EqualFold(f, column) {
+				index = i
+				return "", "", false
+			}
+		}
+		if index < 0 || len(fields) <= index || fields[index] == "" {
+			return "", "", false
+		}
+		return fields[index], "", true
+	}, nil
+}
+
+func parseCSVLine(line string) (string, string, bool) {
+	for _, f := range csvFields(line) {
+		if looksLikeURL(f) {
+			return f, "", true
+		}
+	}
+	return "", "", false
+}
+
+// csvFields splits a csv line, quoted fields may hold commas and a stray quote doesn't make the line unreadable
+func csvFields(line string) []string {
+	r := csv.NewReader(strings.NewReader(line))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	r.TrimLeadingSpace = true
+	fields, err := r.Read()
+	if err != nil {
+		return nil
+	}
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+	return fields
+}
+
+// looksLikeURL tells a url apart from header names, ranks and other numbers in a csv row. The scheme is optional, so
+// a host with a dot and a top level domain of letters is enough, e.g. facebook.com/. Characters a url has to escape,
+// such as the quotes and braces of a json object, rule a field out
+func looksLikeURL(s string) bool {
+	if s == "" || strings.ContainsAny(s, " \t\"{}<>") {
+		return false
+	}
+	if strings.Contains(s, "://") {
+		return true
+	}
+
+	host := s
+	if i := strings.IndexAny(host, "/?#"); i >= 0 {
+		host = host[:i]
+	}
+	if i := strings.LastIndex(host, ":"); i >= 0 {
+		host = host[:i]
+	}
+	dot := strings.LastIndex(host, ".")
+	if dot <= 0 || dot == len(host)-1 {
+		return false
+	}
+	for _, r := range host[dot+1:] {
+		if !unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func parsePlainLine(line string) (string, string, bool) {
+	line = strings.TrimSpace(line)
+	return line, "", line != ""
+}
+
+func parseJSONLine(line string) (string, string, bool) {
+	return parseJSON(line, "url")
+}
+
+// parseJSON reads the url from the field of a json object, or a json string that is the url itself
+func parseJSON(line, field string) (string, string, bool) {
+	if strings.TrimSpace(line) == "" {
+		return "", "", false
+	}
+
+	var v interface{}
+	dec := json.NewDecoder(strings.NewReader(line))
+	// keep numeric ids as written rather than as floats
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return "", "", false
+	}
+
+	switch v := v.(type) {
+	case string:
+		return v, "", v != ""
+	case map[string]interface{}:
+		URL, _ := v[field].(string)
+		if URL == "" {
+			return "", "", false
+		}
+		var id string
+		if v["id"] != nil {
+			id = fmt.Sprint(v["id"])
+		}
+		return URL, id, true
+	}
+	return "", "", false
+}
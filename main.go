@@ -2,16 +2,19 @@
 package main
 
 import (
-	"bufio"
-	"bytes"
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"net/http"
 	"os"
+	"os/signal"
 	"path"
-	"sort"
+	"regexp"
 	"strings"
 	"sync"
+	"syscall"
+	"time"
 
 	log "github.com/marcsantiago/logger"
 	"github.com/marcsantiago/search_keyword/search"
@@ -19,72 +22,96 @@ import (
 
 const logKey = "Main"
 
-func readFromDirectory(dir string, sc *search.Scanner) (err error) {
-	var wg sync.WaitGroup
+func readFromDirectory(dir, format string, parse lineParser, searchURL searchFunc, workers int) (err error) {
 	files, err := ioutil.ReadDir(dir)
 	if err != nil {
 		return
 	}
 
+	// one pool for every file so the limit holds across the whole directory
+	pool := newLinePool(workers, parse, searchURL)
+	defer pool.wait()
+
 	for _, f := range files {
 		name := f.Name()
 		p := path.Join(dir, name)
 
 		// avoid .DS_Store and like files
-		if strings.HasPrefix(name, ".") {
+		if strings.HasPrefix(name, ".") || f.IsDir() {
 			continue
 		}
 
-		file, err := os.Open(p)
-		if err != nil {
-			log.Fatal(logKey, "couldn't open file", "error", err)
-		}
-		defer file.Close()
-
-		scanner := bufio.NewScanner(file)
-		for scanner.Scan() {
-			wg.Add(1)
-			go scan(scanner.Text(), &wg, sc)
+		err := eachLine(p, format, pool.add)
+		if err == errBinaryFile {
+			log.Warn(logKey, "skipping binary file", "file", p)
+			continue
 		}
-
-		if err := scanner.Err(); err != nil {
+		if err != nil {
 			return err
 		}
 	}
-	wg.Wait()
 	return
 }
 
-func readFromFile(path string, sc *search.Scanner) (err error) {
-	var wg sync.WaitGroup
-	file, err := os.Open(path)
-	if err != nil {
-		return
+func readFromFile(path, format string, parse lineParser, searchURL searchFunc, workers int) (err error) {
+	pool := newLinePool(workers, parse, searchURL)
+	defer pool.wait()
+	return eachLine(path, format, pool.add)
+}
+
+// readFromReader searches every line of r, it is how urls piped to stdin are read
+func readFromReader(r io.Reader, format string, parse lineParser, searchURL searchFunc, workers int) (err error) {
+	pool := newLinePool(workers, parse, searchURL)
+	defer pool.wait()
+	return scanLines(r, format, pool.add)
+}
+
+// linePool searches input lines on a fixed number of goroutines, add blocks while they are all busy so huge inputs
+// are never read far ahead of the searches. Lines are parsed as they are added, in order, so a csv header is seen
+// before the rows under it
+type linePool struct {
+	parse lineParser
+	urls  chan inputURL
+	wg    sync.WaitGroup
+}
+
+type inputURL struct {
+	URL, id string
+}
+
+func newLinePool(workers int, parse lineParser, searchURL searchFunc) *linePool {
+	if workers < 1 {
+		workers = 1
 	}
-	defer file.Close()
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		wg.Add(1)
-		go scan(scanner.Text(), &wg, sc)
+	p := &linePool{parse: parse, urls: make(chan inputURL)}
+	p.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer p.wg.Done()
+			for u := range p.urls {
+				scan(u, searchURL)
+			}
+		}()
 	}
-	wg.Wait()
+	return p
+}
 
-	if err = scanner.Err(); err != nil {
+func (p *linePool) add(line string) {
+	URL, id, ok := p.parse(line)
+	if !ok {
 		return
 	}
-	return
+	p.urls <- inputURL{URL: URL, id: id}
 }
 
-func scan(line string, wg *sync.WaitGroup, sc *search.Scanner) {
-	defer wg.Done()
-
-	parts := strings.Split(line, ",")
-	if len(parts) <= 0 {
-		return
-	}
+// wait stops taking lines and returns once every line added has been searched
+func (p *linePool) wait() {
+	close(p.urls)
+	p.wg.Wait()
+}
 
-	URL := strings.Replace(parts[1], "\"", "", -1)
-	err := sc.Search(URL)
+func scan(u inputURL, searchURL searchFunc) {
+	err := searchURL(u.URL, u.id)
 	if err != nil {
 		log.Error(logKey, "search error", "error", err)
 	}
@@ -94,66 +121,325 @@ func scan(line string, wg *sync.WaitGroup, sc *search.Scanner) {
 // the questions requirement, however the package search was written to
 // be more generic
 func main() {
-	inputFile := flag.String("in", "", "the input file path containing the list of urls or folder path containing files pointing to urls")
+	configPath := flag.String("config", "", "a TOML file setting any of these flags by name, plus headers, auth and per domain rate, headers and auth, flags given here win over it")
+	inputFile := flag.String("in", "", "the input file path containing the list of urls or folder path containing files pointing to urls, - or leaving it out reads the urls from stdin")
 	outFile := flag.String("out", "", "output file path")
 	keyword := flag.String("keyword", "", "keyword to search for")
+	mode := flag.String("mode", modeRegex, "regex (-keyword is a regular expression), keyword (-keyword is matched literally), email (scrape email addresses) probe (only record the status code of each url) or sitemap (search the pages listed in each url's sitemap.xml for the -keyword regular expression), -keyword isn't needed for email and probe")
+	baselinePath := flag.String("baseline", "", "the -out file of an earlier run, in the same -format, only the urls that started or stopped matching since then are written")
+	contextSep := flag.String("context-separator", search.DefaultContextSeparator, "joins the emails found on a page in the csv output of email mode")
+	filters := flag.String("filters", "", "comma separated strings, e.g. domains, email addresses containing any of them are left out in email mode")
 	enableLogging := flag.Bool("logging", false, "enables logging")
 	limit := flag.Int("concurrency", 20, "set the limit of goroutines to spin up")
 	depth := flag.Int("depth", 0, "set how depth of the search")
+	format := flag.String("format", formatCSV, "output format, csv, urls (only the urls where the keyword was found, one per line) or jsonl (every result as a json object per line)")
+	urlColumn := flag.String("url-column", "", "the csv column holding the urls, a number counting from 1 or the name of the column in the header row, by default the first column with a url in it, or the field of json objects holding them, url by default")
+	inputFormat := flag.String("input-format", inputCSV, "input line format, csv (url in the first column that holds one, see -url-column), plain (one url per line), jsonl ({\"url\": ..., \"id\": ...} per line) or json (an array of urls or of such objects)")
+	summary := flag.Bool("summary", false, "print a summary of the run when it finishes")
+	statePath := flag.String("state", "", "write every url to this file once it is searched, so an interrupted run can be picked up again with -resume")
+	resume := flag.Bool("resume", false, "skip the urls already in the -state file and add to the -out file instead of replacing it")
+	showProgress := flag.Bool("progress", false, "show a progress bar with the urls done, matches, errors and requests per second on stderr")
+	sanitize := flag.Bool("sanitize", true, "trim surrounding whitespace and quotes from the keyword before it is compiled")
+	splitBy := flag.String("split-by", "", "set to domain to write one csv per domain into the -out directory instead of a single file")
+	serve := flag.String("serve", "", "run as a service on this address e.g :8080, POST /search with {\"url\", \"keyword\", \"depth\"} returns the results as json")
+	serveDepth := flag.Int("serve-max-depth", 3, "the deepest depth a request to the service may ask for")
+	serveTimeout := flag.Duration("serve-timeout", 30*time.Second, "how long a single request to the service may take")
+	timeout := flag.Duration("timeout", search.DefaultTimeout, "how long a single request may take, e.g. 30s")
+	searchTimeout := flag.Duration("search-timeout", 0, "how long a url from the input may take including every page followed from it with -depth, 0 means no limit")
+	hostRate := flag.Float64("host-rate", 0, "the most requests per second sent to any one host, e.g. 0.5 for one every two seconds, 0 means no limit")
+	proxies := flag.String("proxies", "", "comma separated http or https proxy urls that requests are sent through in turn, a proxy failing 3 times in a row is dropped")
+	cookies := flag.String("cookies", "", "a Netscape cookies.txt whose cookies are sent with the requests, cookies pages set are then kept for the rest of the run")
+	transcode := flag.Bool("transcode", false, "decode pages served in another charset, e.g. ISO-8859-1 or Shift_JIS, to utf-8 before matching")
+	userAgent := flag.String("user-agent", "", "the User-Agent sent with every request, Go's default when empty")
+	maxBody := flag.Int64("max-body", 0, "the most bytes read of any response body, longer pages are matched on their start only, 0 means no limit")
+	streamChunk := flag.Int("stream-chunk", 0, "match pages this many bytes at a time as they are read instead of reading them whole, keeping memory bounded, 0 reads them whole. Only regex, keyword and sitemap modes stream, and with -depth the page whose links are followed is still read whole, use -max-body to bound those")
+	compress := flag.Bool("compress", false, "ask for gzip, deflate or brotli bodies, they are decompressed before matching")
+	dedup := flag.Bool("dedup", false, "search every url once, duplicates in the input and pages found again while following links are skipped in every -mode, a url whose fetch failed is tried again when it comes up. In serve mode it holds within each request")
+	robots := flag.Bool("robots", false, "skip links disallowed by each site's robots.txt and wait its Crawl-delay between requests")
+	retries := flag.Int("retries", 0, "how many times a request that times out or gets a 5xx is retried, waiting longer each time")
+	retryJitter := flag.Float64("retry-jitter", 0.5, "the fraction, from 0 to 1, of each wait before a retry that is random so urls failing together don't retry together, 0 waits exactly")
 	flag.Parse()
 
-	if *inputFile == "" {
+	var cfg *config
+	if *configPath != "" {
+		var err error
+		if cfg, err = loadConfig(*configPath); err == nil {
+			err = cfg.setFlags(flag.CommandLine)
+		}
+		if err != nil {
+			log.Fatal(logKey, "couldn't read config", "error", err)
+		}
+	}
+
+	if *timeout <= 0 {
+		flag.PrintDefaults()
+		log.Fatal(logKey, "timeout must be positive", "timeout", *timeout)
+	}
+	if *searchTimeout < 0 {
+		flag.PrintDefaults()
+		log.Fatal(logKey, "search-timeout cannot be negative", "search-timeout", *searchTimeout)
+	}
+	if *hostRate < 0 {
+		flag.PrintDefaults()
+		log.Fatal(logKey, "host-rate cannot be negative", "host-rate", *hostRate)
+	}
+	if *retries < 0 {
 		flag.PrintDefaults()
-		log.Fatal(logKey, "input file path cannot be empty")
+		log.Fatal(logKey, "retries cannot be negative", "retries", *retries)
+	}
+	if *retryJitter < 0 || *retryJitter > 1 {
+		flag.PrintDefaults()
+		log.Fatal(logKey, "retry-jitter must be from 0 to 1", "retry-jitter", *retryJitter)
+	}
+
+	var pool *search.ProxyPool
+	if *proxies != "" {
+		var err error
+		if pool, err = search.NewProxyPool(splitList(*proxies)); err != nil {
+			log.Fatal(logKey, "couldn't set up proxies", "error", err)
+		}
+	}
+
+	// settings applies the flags and config file to a scanner's fields, in serve mode to the scanner of every request.
+	// The client is shared between those so its settings are left to setup
+	settings := func(sc *search.Scanner) {
+		sc.SearchTimeout = *searchTimeout
+		sc.Retries = *retries
+		sc.HostRate = *hostRate
+		sc.RespectRobots = *robots
+		sc.DedupURLs = *dedup
+		sc.UserAgent = *userAgent
+		sc.Compression = *compress
+		sc.MaxBodyBytes = *maxBody
+		sc.StreamChunkBytes = *streamChunk
+		sc.TranscodeCharset = *transcode
+		sc.RetryJitter = *retryJitter
+		if cfg != nil {
+			cfg.apply(sc)
+		}
+	}
+	// setup is settings plus the client's timeout, proxies and cookies, done once per client
+	setup := func(sc *search.Scanner) {
+		settings(sc)
+		sc.Client.Timeout = *timeout
+		if pool != nil {
+			if err := sc.UseProxyPool(pool); err != nil {
+				log.Fatal(logKey, "couldn't set up proxies", "error", err)
+			}
+		}
+		if *cookies != "" {
+			if err := sc.LoadCookiesFile(*cookies); err != nil {
+				log.Fatal(logKey, "couldn't load cookies", "error", err)
+			}
+		}
+	}
+
+	if *serve != "" {
+		if *serveDepth < 0 {
+			log.Fatal(logKey, "serve-max-depth cannot be negative", "serve-max-depth", *serveDepth)
+		}
+		sc := search.NewScanner(*limit, 0, *enableLogging, "")
+		setup(sc)
+		log.Info(logKey, "serving", "address", *serve)
+		handler := newSearchHandler(sc, settings, *serveDepth, *serveTimeout)
+		log.Fatal(logKey, "server stopped", "error", http.ListenAndServe(*serve, handler))
 	}
 
+	fromStdin := *inputFile == "" || *inputFile == "-"
+
 	if *outFile == "" {
 		flag.PrintDefaults()
 		log.Fatal(logKey, "out file path cannot be empty")
 	}
 
-	if *keyword == "" {
+	if *sanitize {
+		*keyword = search.SanitizeKeyword(*keyword)
+	}
+
+	if *keyword == "" && *mode != modeEmail && *mode != modeProbe {
 		flag.PrintDefaults()
 		log.Fatal(logKey, "keyword cannot be empty")
 	}
 
-	fi, err := os.Stat(*inputFile)
+	// the scanner compiles the keyword as a regular expression either way
+	pattern := *keyword
+	if *mode == modeKeyword {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if _, err := search.CompilePattern(pattern, search.KeywordOptions{}); err != nil {
+		log.Fatal(logKey, "invalid keyword", "error", err)
+	}
+
+	parse, err := parserFor(*inputFormat, *urlColumn)
 	if err != nil {
-		log.Fatal(logKey, "os.Stat", "error", err)
+		flag.PrintDefaults()
+		log.Fatal(logKey, "bad input format", "format", *inputFormat, "error", err)
 	}
 
-	sc := search.NewScanner(*limit, *depth, *enableLogging, *keyword)
-	switch mode := fi.Mode(); {
-	case mode.IsDir():
-		err := readFromDirectory(*inputFile, sc)
+	var fi os.FileInfo
+	if !fromStdin {
+		fi, err = os.Stat(*inputFile)
 		if err != nil {
-			log.Fatal(logKey, "could not read from directory", "error", err)
+			log.Fatal(logKey, "os.Stat", "error", err)
 		}
-	case mode.IsRegular():
-		err := readFromFile(*inputFile, sc)
-		if err != nil {
-			log.Fatal(logKey, "could not read from file", "error", err)
+	}
+
+	if (*statePath != "" || *resume) && (*splitBy != "" || *baselinePath != "") {
+		log.Fatal(logKey, "-state and -resume only work with results written to a single file as they come in")
+	}
+	if *resume && *statePath == "" {
+		log.Fatal(logKey, "-resume needs the -state file of the run being resumed")
+	}
+
+	var baseline search.Results
+	if *baselinePath != "" {
+		if *splitBy != "" || *mode == modeProbe || *mode == modeEmail {
+			log.Fatal(logKey, "-baseline only works with keyword searches written to a single file")
+		}
+		if baseline, err = readBaseline(*baselinePath, *format, pattern); err != nil {
+			log.Fatal(logKey, "couldn't read baseline", "error", err)
 		}
 	}
 
-	var buf bytes.Buffer
-	header := fmt.Sprintf("search for keyword %s\nurl,found,context\n", *keyword)
-	_, err = buf.WriteString(header)
+	sc := search.NewScanner(*limit, *depth, *enableLogging, pattern)
+	sc.ContextSeparator = *contextSep
+	setup(sc)
+
+	searchURL, err := searcherFor(*mode, sc, splitList(*filters))
 	if err != nil {
-		log.Error(logKey, "buffer could not write initial string")
+		flag.PrintDefaults()
+		log.Fatal(logKey, "unknown mode", "mode", *mode)
 	}
 
-	sort.Sort(sc.Results)
-	for _, r := range sc.Results {
-		line := fmt.Sprintf("%s, %v, %v\n", r.URL, r.Found, r.Context)
-		_, err = buf.WriteString(line)
+	// split output and diffs are written once the run is done, otherwise rows are streamed to the file as they come in
+	var (
+		rw  *resultWriter
+		out *os.File
+	)
+	switch {
+	case *splitBy == "" && *baselinePath != "":
+		if out, err = os.Create(*outFile); err != nil {
+			log.Fatal(logKey, "couldn't create file", "error", err)
+		}
+		defer out.Close()
+	case *splitBy == "":
+		flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+		if *resume {
+			flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		}
+		if out, err = os.OpenFile(*outFile, flags, 0644); err != nil {
+			log.Fatal(logKey, "couldn't create file", "error", err)
+		}
+		defer out.Close()
+
+		rw, err = newResultWriter(out, *format, *inputFormat == inputJSONL || *inputFormat == inputJSON)
 		if err != nil {
-			log.Fatal(logKey, "couldn't write string", "message", line)
+			flag.PrintDefaults()
+			log.Fatal(logKey, "unknown output format", "format", *format)
+		}
+
+		rw.probe = *mode == modeProbe
+		rw.sep = *contextSep
+		title := "search for keyword " + *keyword
+		switch *mode {
+		case modeEmail:
+			title = "search for emails"
+		case modeProbe:
+			title = "probe"
+		}
+		// a resumed run carries on below the header it already wrote
+		if st, err := out.Stat(); err != nil || st.Size() == 0 {
+			if err := rw.writeHeader(title); err != nil {
+				log.Fatal(logKey, "couldn't write header", "error", err)
+			}
+		}
+		sc.OnResult = rw.write
+		sc.DiscardResults = true
+	case *splitBy == "domain":
+		if *format != formatCSV {
+			log.Fatal(logKey, "split output is only written as csv", "format", *format)
+		}
+	default:
+		flag.PrintDefaults()
+		log.Fatal(logKey, "unknown split", "split-by", *splitBy)
+	}
+
+	var cp *checkpoint
+	if *statePath != "" {
+		if cp, err = openCheckpoint(*statePath, *resume); err != nil {
+			log.Fatal(logKey, "couldn't open state file", "error", err)
+		}
+		cp.before = rw.flush
+		searchURL = cp.wrap(searchURL)
+
+		// an interrupted run keeps what it has done so far
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-sigs
+			if err := cp.close(); err != nil {
+				log.Error(logKey, "couldn't save state", "error", err)
+			}
+			os.Exit(130)
+		}()
+	}
+
+	var bar *progress
+	if *showProgress {
+		// stdin can only be read once, the bar stays empty and the counts still go up
+		if !fromStdin {
+			n, err := countURLs(*inputFile, *inputFormat, fi.Mode().IsDir(), parse)
+			if err != nil {
+				log.Fatal(logKey, "couldn't count the input urls", "error", err)
+			}
+			if cp != nil && cp.len() < n {
+				n -= cp.len()
+			}
+			sc.SetTotal(n)
+		}
+		bar = startProgress(os.Stderr, sc, 500*time.Millisecond)
+	}
+
+	switch {
+	case fromStdin:
+		err := readFromReader(os.Stdin, *inputFormat, parse, searchURL, *limit)
+		if err != nil {
+			log.Fatal(logKey, "could not read from stdin", "error", err)
+		}
+	case fi.Mode().IsDir():
+		err := readFromDirectory(*inputFile, *inputFormat, parse, searchURL, *limit)
+		if err != nil {
+			log.Fatal(logKey, "could not read from directory", "error", err)
+		}
+	case fi.Mode().IsRegular():
+		err := readFromFile(*inputFile, *inputFormat, parse, searchURL, *limit)
+		if err != nil {
+			log.Fatal(logKey, "could not read from file", "error", err)
+		}
+	}
+	if bar != nil {
+		bar.stop()
+	}
+	if cp != nil {
+		signal.Reset(os.Interrupt, syscall.SIGTERM)
+		if err := cp.close(); err != nil {
+			log.Fatal(logKey, "couldn't save state", "error", err)
 		}
 	}
 
-	err = ioutil.WriteFile(*outFile, buf.Bytes(), 0644)
+	switch {
+	case out != nil && rw == nil:
+		err = writeDiff(out, search.DiffResults(baseline, sc.GetResults()), *format)
+	case rw != nil:
+		err = rw.flush()
+	default:
+		err = sc.WriteResultsByDomain(*outFile, search.FormatCSV)
+	}
 	if err != nil {
-		log.Fatal(logKey, "couldn't write file", "error", err)
+		log.Fatal(logKey, "couldn't write output", "error", err)
+	}
+
+	if *summary {
+		fmt.Println(sc.Summary())
 	}
 }
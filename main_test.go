@@ -0,0 +1,694 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/marcsantiago/search_keyword/search"
+)
+
+func gzipped(t *testing.T, b []byte) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(b); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func tarred(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, body := range files {
+		hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(body)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// collect returns a scanner that records every searched url
+func collect() (*search.Scanner, func() []string) {
+	var (
+		mu   sync.Mutex
+		urls []string
+	)
+	sc := search.NewScanner(4, 0, false, "sign up")
+	sc.OnResult = func(r search.Result) {
+		mu.Lock()
+		urls = append(urls, r.URL)
+		mu.Unlock()
+	}
+	return sc, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		sort.Strings(urls)
+		return urls
+	}
+}
+
+func TestReadFromDirectoryCompressed(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<p>sign up</p>")
+	}))
+	defer ts.Close()
+
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"plain.txt":     []byte(fmt.Sprintf("1,\"%s/plain\"\n", ts.URL)),
+		"zipped.txt.gz": gzipped(t, []byte(fmt.Sprintf("2,\"%s/gz\"\n", ts.URL))),
+		"archive.tar.gz": gzipped(t, tarred(t, map[string]string{
+			"list.txt": fmt.Sprintf("3,\"%s/tar\"\n", ts.URL),
+		})),
+		"image.png": {0x89, 'P', 'N', 'G', 0x00, 0x00, 0x01},
+	}
+	for name, b := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), b, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sc, urls := collect()
+	if err := readFromDirectory(dir, inputCSV, parseCSVLine, sc.SearchWithID, 4); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{ts.URL + "/gz", ts.URL + "/plain", ts.URL + "/tar"}
+	got := urls()
+	if len(got) != len(want) {
+		t.Fatalf("expected %v got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("expected %s got %s", want[i], got[i])
+		}
+	}
+}
+
+func TestIsBinary(t *testing.T) {
+	if isBinary([]byte("1,\"http://example.com\"\n")) {
+		t.Errorf("a url list should not be binary")
+	}
+
+	if !isBinary([]byte{'a', 0x00, 'b'}) {
+		t.Errorf("NUL bytes should be binary")
+	}
+
+	// a multi byte rune cut off at the end of the peek is still text
+	if isBinary([]byte("café")[:4]) {
+		t.Errorf("a cut off rune should not be binary")
+	}
+}
+
+func TestReadFromFileJSONL(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<p>sign up</p>")
+	}))
+	defer ts.Close()
+
+	p := filepath.Join(t.TempDir(), "urls.jsonl")
+	lines := fmt.Sprintf("{\"url\": \"%[1]s/a\", \"id\": \"first\"}\n\n{\"url\": \"%[1]s/b\", \"id\": 42}\nnot json\n{\"id\": \"no url\"}\n", ts.URL)
+	if err := ioutil.WriteFile(p, []byte(lines), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		mu  sync.Mutex
+		ids = make(map[string]string)
+	)
+	sc := search.NewScanner(4, 0, false, "sign up")
+	sc.OnResult = func(r search.Result) {
+		mu.Lock()
+		ids[r.URL] = r.ID
+		mu.Unlock()
+	}
+	if err := readFromFile(p, inputJSONL, parseJSONLine, sc.SearchWithID, 4); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{ts.URL + "/a": "first", ts.URL + "/b": "42"}
+	if len(ids) != len(want) {
+		t.Fatalf("expected %v got %v", want, ids)
+	}
+	for u, id := range want {
+		if ids[u] != id {
+			t.Errorf("expected %s to have id %q got %q", u, id, ids[u])
+		}
+	}
+}
+
+func TestReadFromReader(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		seen = make(map[string]bool)
+	)
+	searchURL := func(URL, id string) error {
+		mu.Lock()
+		seen[URL] = true
+		mu.Unlock()
+		return nil
+	}
+	stdin := strings.NewReader("http://example.com/a\n\nhttp://example.com/b\n")
+	if err := readFromReader(stdin, inputPlain, parsePlainLine, searchURL, 2); err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != 2 || !seen["http://example.com/a"] || !seen["http://example.com/b"] {
+		t.Errorf("expected both piped urls to be searched got %v", seen)
+	}
+}
+
+func TestJSONInput(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		column string
+		want   []string
+	}{
+		{"array of urls", `["http://a.com", "", 7, "http://b.com"]`, "", []string{"http://a.com", "http://b.com"}},
+		{"array of objects", "\ufeff[\n  {\"url\": \"http://a.com\", \"id\": 1},\n  {\"link\": \"http://b.com\"}\n]", "", []string{"http://a.com 1"}},
+		{"field name", `[{"url": "http://a.com"}, {"link": "http://b.com", "id": "b"}]`, "link", []string{"http://b.com b"}},
+		{"ndjson", "{\"link\": \"http://a.com\"}\n{\"link\": \"http://b.com\"}\n", "link", []string{"http://a.com", "http://b.com"}},
+	}
+	for _, tt := range tests {
+		parse, err := parserFor(inputJSON, tt.column)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		err = scanLines(strings.NewReader(tt.input), inputJSON, func(line string) {
+			if URL, id, ok := parse(line); ok {
+				got = append(got, strings.TrimSpace(URL+" "+id))
+			}
+		})
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("%s: expected %q got %q", tt.name, tt.want, got)
+		}
+	}
+
+	if err := scanLines(strings.NewReader(`["http://a.com"`), inputJSON, func(string) {}); err == nil {
+		t.Error("expected an unterminated array to be an error")
+	}
+
+	// only json input is read as an array, csv reads the lines as they are and none of them is a url
+	parse, err := parserFor(inputCSV, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines, urls []string
+	err = scanLines(strings.NewReader("[\n{\"url\":\"http://a.com\"}\n]\n"), inputCSV, func(line string) {
+		lines = append(lines, line)
+		if URL, _, ok := parse(line); ok {
+			urls = append(urls, URL)
+		}
+	})
+	if err != nil || len(lines) != 3 || len(urls) != 0 {
+		t.Errorf("expected csv input to be read line by line without urls, got lines %q urls %q %v", lines, urls, err)
+	}
+}
+
+func TestParsePlainLine(t *testing.T) {
+	if URL, _, ok := parsePlainLine("  http://example.com \r"); !ok || URL != "http://example.com" {
+		t.Errorf("expected the trimmed url got %q", URL)
+	}
+	if _, _, ok := parsePlainLine("   "); ok {
+		t.Error("expected blank lines to be skipped")
+	}
+}
+
+func TestCSVParser(t *testing.T) {
+	lines := []string{
+		`"Rank","URL","Name"`,
+		`1,"facebook.com/",9.54`,
+		`2,"http://example.com/a,b","Example, Inc"`,
+		`3`,
+		`4,"bad "quote" field",example.org`,
+	}
+	tests := []struct {
+		column string
+		want   []string
+	}{
+		{"", []string{"facebook.com/", "http://example.com/a,b", "example.org"}},
+		{"2", []string{"facebook.com/", "http://example.com/a,b"}},
+		{"url", []string{"facebook.com/", "http://example.com/a,b", `bad "quote" field`}},
+		{"name", []string{"9.54", "Example, Inc", "example.org"}},
+	}
+	for _, tt := range tests {
+		parse, err := parserFor(inputCSV, tt.column)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, line := range lines {
+			if URL, _, ok := parse(line); ok {
+				got = append(got, URL)
+			}
+		}
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("column %q expected %q got %q", tt.column, tt.want, got)
+		}
+	}
+
+	if URL, _, ok := parseCSVLine("http://example.com"); !ok || URL != "http://example.com" {
+		t.Errorf("expected a single column line to be read got %q", URL)
+	}
+	if _, err := parserFor(inputCSV, "0"); err == nil {
+		t.Error("expected column 0 to be an error")
+	}
+	if _, err := parserFor(inputPlain, "url"); err == nil {
+		t.Error("expected a url column to be an error for plain input")
+	}
+}
+
+func TestEmailMode(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/contact":
+			fmt.Fprint(w, "<p>write to sales@example.com</p>")
+		case "/spam":
+			fmt.Fprint(w, "<p>write to bot@spam.com or bot@junk.com</p>")
+		case "/mixed":
+			fmt.Fprint(w, "<p>bot@spam.com, sales@example.com and noreply@junk.com</p>")
+		default:
+			fmt.Fprint(w, "<p>nothing here</p>")
+		}
+	}))
+	defer ts.Close()
+
+	p := filepath.Join(t.TempDir(), "urls.txt")
+	lines := fmt.Sprintf("%[1]s/contact\n%[1]s/spam\n%[1]s/mixed\n%[1]s/empty\n", ts.URL)
+	if err := ioutil.WriteFile(p, []byte(lines), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	rw, err := newResultWriter(&out, formatCSV, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc := search.NewScanner(4, 0, false, "")
+	sc.OnResult = rw.write
+	searchURL, err := searcherFor(modeEmail, sc, splitList("spam.com, junk.com, "))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := readFromFile(p, inputPlain, parsePlainLine, searchURL, 4); err != nil {
+		t.Fatal(err)
+	}
+	if err := rw.flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	rows := []string{
+		ts.URL + "/contact,true,sales@example.com,",
+		ts.URL + "/spam,false,,",
+		ts.URL + "/mixed,true,sales@example.com,",
+		ts.URL + "/empty,false,,",
+	}
+	for _, row := range rows {
+		if !strings.Contains(out.String(), row) {
+			t.Errorf("expected a row starting %q in\n%s", row, out.String())
+		}
+	}
+
+	if _, err := searcherFor("grep", sc, nil); err == nil {
+		t.Error("expected an unknown mode to error")
+	}
+}
+
+func TestReadFromFileWorkers(t *testing.T) {
+	const lines, workers = 10000, 3
+
+	var b strings.Builder
+	for i := 0; i < lines; i++ {
+		fmt.Fprintf(&b, "http://example%d.com\n", i)
+	}
+	p := filepath.Join(t.TempDir(), "urls.txt")
+	if err := ioutil.WriteFile(p, []byte(b.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		mu                 sync.Mutex
+		searched, cur, max int
+		maxGoroutines      int
+		goroutines         = runtime.NumGoroutine()
+	)
+	searchURL := func(URL, id string) error {
+		mu.Lock()
+		searched++
+		if cur++; cur > max {
+			max = cur
+		}
+		if n := runtime.NumGoroutine(); n > maxGoroutines {
+			maxGoroutines = n
+		}
+		mu.Unlock()
+
+		runtime.Gosched()
+
+		mu.Lock()
+		cur--
+		mu.Unlock()
+		return nil
+	}
+	if err := readFromFile(p, inputPlain, parsePlainLine, searchURL, workers); err != nil {
+		t.Fatal(err)
+	}
+
+	if searched != lines {
+		t.Errorf("expected %d searches got %d", lines, searched)
+	}
+	if max > workers {
+		t.Errorf("expected at most %d searches at once got %d", workers, max)
+	}
+	if maxGoroutines > goroutines+workers {
+		t.Errorf("expected at most %d goroutines got %d", goroutines+workers, maxGoroutines)
+	}
+}
+
+func TestModeIDs(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<p>write to sales@example.com</p>")
+	}))
+	defer ts.Close()
+
+	for _, mode := range []string{modeRegex, modeEmail, modeProbe} {
+		sc := search.NewScanner(2, 0, false, "write")
+		var ids []string
+		sc.OnResult = func(r search.Result) {
+			ids = append(ids, r.ID)
+		}
+		searchURL, err := searcherFor(mode, sc, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := searchURL(ts.URL+"/contact", "row-7"); err != nil {
+			t.Fatal(err)
+		}
+		if len(ids) != 1 || ids[0] != "row-7" {
+			t.Errorf("%s: expected the input id on the result, got %q", mode, ids)
+		}
+	}
+}
+
+func TestProbeMode(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/gone" {
+			w.WriteHeader(http.StatusGone)
+		}
+	}))
+	defer ts.Close()
+
+	p := filepath.Join(t.TempDir(), "urls.txt")
+	if err := ioutil.WriteFile(p, []byte(fmt.Sprintf("%[1]s/up\n%[1]s/gone\n", ts.URL)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for format, rows := range map[string][]string{
+		formatCSV:  {"url,status,duration\n", ts.URL + "/up,200,", ts.URL + "/gone,410,"},
+		formatURLs: {ts.URL + "/up\n"},
+	} {
+		var out bytes.Buffer
+		rw, err := newResultWriter(&out, format, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rw.probe = true
+		if err := rw.writeHeader("probe"); err != nil {
+			t.Fatal(err)
+		}
+
+		sc := search.NewScanner(2, 0, false, "")
+		sc.OnResult = rw.write
+		searchURL, err := searcherFor(modeProbe, sc, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := readFromFile(p, inputPlain, parsePlainLine, searchURL, 2); err != nil {
+			t.Fatal(err)
+		}
+		if err := rw.flush(); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, row := range rows {
+			if !strings.Contains(out.String(), row) {
+				t.Errorf("%s: expected %q in\n%s", format, row, out.String())
+			}
+		}
+		if format == formatURLs && strings.Contains(out.String(), "/gone") {
+			t.Errorf("expected only live urls, got\n%s", out.String())
+		}
+	}
+}
+
+func TestBaselineDiff(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "before.csv")
+	before := "search for keyword sign up\nurl,found,context,duration\n" +
+		"http://a.com,true,sign up,1ms\nhttp://b.com,false,,1ms\nhttp://c.com,true,sign up,1ms\n"
+	if err := ioutil.WriteFile(p, []byte(before), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	baseline, err := readBaseline(p, formatCSV, "sign up")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(baseline) != 3 {
+		t.Fatalf("expected 3 baseline results, got %d", len(baseline))
+	}
+
+	now := search.Results{
+		{URL: "http://a.com", Keyword: "sign up", Found: true},
+		{URL: "http://b.com", Keyword: "sign up", Found: true},
+		{URL: "http://c.com", Keyword: "sign up"},
+		{URL: "http://d.com", Keyword: "sign up", Error: "timeout"},
+	}
+	for format, want := range map[string]string{
+		formatCSV:  "url,change\nhttp://b.com,matched\nhttp://c.com,unmatched\n",
+		formatURLs: "http://b.com\nhttp://c.com\n",
+	} {
+		var out bytes.Buffer
+		if err := writeDiff(&out, search.DiffResults(baseline, now), format); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != want {
+			t.Errorf("%s: expected\n%s\ngot\n%s", format, want, out.String())
+		}
+	}
+}
+
+func TestJSONLOutput(t *testing.T) {
+	var out bytes.Buffer
+	rw, err := newResultWriter(&out, formatJSONL, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rw.writeHeader("search for keyword sign up"); err != nil {
+		t.Fatal(err)
+	}
+	rw.write(search.Result{URL: "http://a.com", Found: true, Context: "<p>sign up, now</p>"})
+	rw.write(search.Result{URL: "http://b.com"})
+	if err := rw.flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected a json object per result and no title, got\n%s", out.String())
+	}
+	var r search.Result
+	if err := json.Unmarshal([]byte(lines[0]), &r); err != nil {
+		t.Fatal(err)
+	}
+	if r.URL != "http://a.com" || !r.Found || r.Context != "<p>sign up, now</p>" {
+		t.Errorf("expected the first result back, got %+v", r)
+	}
+}
+
+func TestProgress(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/match" {
+			fmt.Fprint(w, "<p>sign up</p>")
+		}
+	}))
+	defer ts.Close()
+
+	dir := t.TempDir()
+	lines := fmt.Sprintf("%[1]s/match\n\n%[1]s/a\n%[1]s/b\n%[1]s/c\n", ts.URL)
+	if err := ioutil.WriteFile(filepath.Join(dir, "urls.txt"), []byte(lines), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, ".hidden"), []byte(lines), 0644); err != nil {
+		t.Fatal(err)
+	}
+	n, err := countURLs(dir, inputPlain, true, parsePlainLine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 4 {
+		t.Fatalf("expected 4 urls, got %d", n)
+	}
+
+	sc := search.NewScanner(1, 0, false, "sign up")
+	sc.SetTotal(n)
+	for _, u := range []string{ts.URL + "/match", ts.URL + "/a"} {
+		if err := sc.Search(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p := &progress{sc: sc}
+	expected := "[###############...............]  50% 2/4 urls, 1 matched, 0 errors, 2.0 req/s"
+	if got := p.line(time.Second); got != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, got)
+	}
+}
+
+func TestCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scan.state")
+	var searched []string
+	record := func(URL, id string) error {
+		searched = append(searched, URL)
+		return nil
+	}
+
+	cp, err := openCheckpoint(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	flushed := false
+	cp.before = func() error {
+		flushed = true
+		return nil
+	}
+	searchURL := cp.wrap(record)
+	searchURL("http://a.com", "")
+	searchURL("http://b.com", "row-2")
+	if err := cp.close(); err != nil {
+		t.Fatal(err)
+	}
+	if !flushed {
+		t.Error("the output should be flushed before the state")
+	}
+
+	// a resumed run only searches what is left
+	if cp, err = openCheckpoint(path, true); err != nil {
+		t.Fatal(err)
+	}
+	if cp.len() != 2 {
+		t.Errorf("expected 2 urls done, got %d", cp.len())
+	}
+	searched = nil
+	searchURL = cp.wrap(record)
+	for _, u := range []string{"http://a.com", "http://b.com", "http://c.com"} {
+		searchURL(u, "")
+	}
+	searchURL("http://b.com", "row-2")
+	if err := cp.close(); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(searched, ",") != "http://b.com,http://c.com" {
+		t.Errorf("expected only b without its id and c to be searched, got %v", searched)
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "http://a.com\nhttp://b.com\trow-2\nhttp://b.com\nhttp://c.com\n"
+	if string(b) != expected {
+		t.Errorf("expected state\n%q\ngot\n%q", expected, b)
+	}
+}
+
+func TestConfig(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "scan.toml")
+	file := `keyword = "sign up"
+concurrency = 50
+timeout = "30s"
+host-rate = 2.5
+retry-jitter = 0
+proxies = ["http://10.0.0.1:3128", "http://10.0.0.2:3128"]
+
+[headers]
+Accept-Language = "en"
+
+[domains."a.com"]
+rate = 0.5
+headers = { Accept-Language = "fr" }
+
+[domains."b.com:8443".auth]
+token = "t0ken"
+`
+	if err := ioutil.WriteFile(p, []byte(file), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := loadConfig(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	keyword := fs.String("keyword", "", "")
+	concurrency := fs.Int("concurrency", 20, "")
+	timeout := fs.Duration("timeout", time.Second, "")
+	hostRate := fs.Float64("host-rate", 0, "")
+	retryJitter := fs.Float64("retry-jitter", 0.5, "")
+	proxies := fs.String("proxies", "", "")
+	if err := fs.Parse([]string{"-concurrency", "5"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.setFlags(fs); err != nil {
+		t.Fatal(err)
+	}
+	if *keyword != "sign up" || *concurrency != 5 || *timeout != 30*time.Second || *hostRate != 2.5 ||
+		*retryJitter != 0 || *proxies != "http://10.0.0.1:3128,http://10.0.0.2:3128" {
+		t.Errorf("expected the config with the command line winning, got %q %d %v %v %v %q", *keyword, *concurrency,
+			*timeout, *hostRate, *retryJitter, *proxies)
+	}
+
+	sc := search.NewScanner(1, 0, false, "")
+	cfg.apply(sc)
+	if sc.Header.Get("Accept-Language") != "en" || sc.HostHeader["a.com"].Get("Accept-Language") != "fr" ||
+		sc.HostRates["a.com"] != 0.5 || len(sc.HostRates) != 1 || sc.HostAuth["b.com:8443"].Token != "t0ken" {
+		t.Errorf("expected the headers and domain settings on the scanner, got %+v %+v %+v %+v", sc.Header,
+			sc.HostHeader, sc.HostRates, sc.HostAuth)
+	}
+
+	if err := ioutil.WriteFile(p, []byte(`concurency = 5`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err = loadConfig(p); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.setFlags(fs); err == nil {
+		t.Error("expected an unknown key to error")
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/marcsantiago/search_keyword/search"
+)
+
+const (
+	// modeRegex matches -keyword as a regular expression, the original behaviour
+	modeRegex = "regex"
+	// modeKeyword matches -keyword literally so characters like + and ( need no escaping
+	modeKeyword = "keyword"
+	// modeEmail scrapes the pages for email addresses, -keyword isn't used
+	modeEmail = "email"
+	// modeProbe only checks which urls answer and with what status, -keyword isn't used
+	modeProbe = "probe"
+	// modeSitemap searches every page listed in the sitemap of each input url for -keyword as a regular expression
+	modeSitemap = "sitemap"
+)
+
+// searchFunc searches the url read from an input line, id is the line's id if it had one
+type searchFunc func(URL, id string) error
+
+// searcherFor returns how urls are searched in the given mode, filters only apply to email mode where addresses
+// containing any of them are dropped
+func searcherFor(mode string, sc *search.Scanner, filters []string) (searchFunc, error) {
+	switch mode {
+	case modeRegex, modeKeyword:
+		return sc.SearchWithID, nil
+	case modeEmail:
+		return func(URL, id string) error {
+			return sc.SearchForEmailContext(search.WithID(context.Background(), id), URL, nil, filters)
+		}, nil
+	case modeProbe:
+		return func(URL, id string) error {
+			_, err := sc.ProbeContext(search.WithID(context.Background(), id), URL)
+			return err
+		}, nil
+	case modeSitemap:
+		return func(URL, id string) error {
+			return sc.CrawlSitemap(search.WithID(context.Background(), id), URL, sc.Keyword)
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown mode %q", mode)
+}
+
+// splitList splits a comma separated flag value such as -filters, empty entries are dropped
+func splitList(s string) (list []string) {
+	for _, f := range strings.Split(s, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			list = append(list, f)
+		}
+	}
+	return
+}
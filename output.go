@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+
+	log "github.com/marcsantiago/logger"
+	"github.com/marcsantiago/search_keyword/search"
+)
+
+const (
+	// formatCSV writes every result as a url,found,context,duration row
+	formatCSV = "csv"
+	// formatURLs writes only the urls where the keyword was found, one per line
+	formatURLs = "urls"
+	// formatJSONL writes every result as a json object on its own line
+	formatJSONL = "jsonl"
+)
+
+// flushEvery is how many rows are buffered before the output is flushed to disk
+const flushEvery = 50
+
+// resultWriter writes results to the output as they come in so a crash still leaves the rows found so far
+type resultWriter struct {
+	mu     sync.Mutex
+	format string
+	buf    *bufio.Writer
+	csv    *csv.Writer
+	json   *json.Encoder
+	rows   int
+	// ids adds the id of the input line as a last csv column
+	ids bool
+	// probe writes url,status,duration rows and treats urls answering below 400 as found
+	probe bool
+	// sep joins list contexts such as the emails found in email mode
+	sep string
+}
+
+func newResultWriter(w io.Writer, format string, ids bool) (*resultWriter, error) {
+	switch format {
+	case formatCSV, formatURLs, formatJSONL:
+	default:
+		return nil, fmt.Errorf("unknown output format %q", format)
+	}
+
+	buf := bufio.NewWriter(w)
+	return &resultWriter{format: format, buf: buf, csv: csv.NewWriter(buf), json: json.NewEncoder(buf), ids: ids,
+		sep: search.DefaultContextSeparator}, nil
+}
+
+// writeHeader writes the title line followed by the csv header
+func (rw *resultWriter) writeHeader(title string) error {
+	if rw.format != formatCSV {
+		return nil
+	}
+
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	fmt.Fprintln(rw.buf, title)
+	header := []string{"url", "found", "context", "duration"}
+	if rw.probe {
+		header = []string{"url", "status", "duration"}
+	}
+	if rw.ids {
+		header = append(header, "id")
+	}
+	rw.csv.Write(header)
+	rw.csv.Flush()
+	if err := rw.csv.Error(); err != nil {
+		return err
+	}
+	return rw.buf.Flush()
+}
+
+func (rw *resultWriter) write(r search.Result) {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+
+	var err error
+	switch rw.format {
+	case formatURLs:
+		if !r.Found && !(rw.probe && r.StatusCode > 0 && r.StatusCode < 400) {
+			return
+		}
+		_, err = fmt.Fprintln(rw.buf, r.URL)
+	case formatJSONL:
+		err = rw.json.Encode(r)
+	default:
+		row := []string{r.URL, strconv.FormatBool(r.Found), search.ContextString(r.Context, rw.sep), r.Duration.String()}
+		if rw.probe {
+			row = []string{r.URL, strconv.Itoa(r.StatusCode), r.Duration.String()}
+		}
+		if rw.ids {
+			row = append(row, r.ID)
+		}
+		err = rw.csv.Write(row)
+	}
+	if err != nil {
+		log.Error(logKey, "couldn't write row", "url", r.URL, "error", err)
+		return
+	}
+
+	rw.rows++
+	if rw.rows%flushEvery == 0 {
+		rw.csv.Flush()
+		rw.buf.Flush()
+	}
+}
+
+func (rw *resultWriter) flush() error {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	rw.csv.Flush()
+	if err := rw.csv.Error(); err != nil {
+		return err
+	}
+	return rw.buf.Flush()
+}
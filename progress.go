@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/marcsantiago/search_keyword/search"
+)
+
+// barWidth is how many characters the progress bar is drawn with
+const barWidth = 30
+
+// countURLs counts the input lines that will be searched, so the progress bar knows how far along the run is. A
+// directory is read the same way readFromDirectory reads it
+func countURLs(input, format string, dir bool, parse lineParser) (int, error) {
+	paths := []string{input}
+	if dir {
+		files, err := ioutil.ReadDir(input)
+		if err != nil {
+			return 0, err
+		}
+		paths = paths[:0]
+		for _, f := range files {
+			if !strings.HasPrefix(f.Name(), ".") && !f.IsDir() {
+				paths = append(paths, path.Join(input, f.Name()))
+			}
+		}
+	}
+
+	n := 0
+	for _, p := range paths {
+		err := eachLine(p, format, func(line string) {
+			if _, _, ok := parse(line); ok {
+				n++
+			}
+		})
+		if err != nil && err != errBinaryFile {
+			return 0, err
+		}
+	}
+	return n, nil
+}
+
+// progress redraws a one line status of the scan, the bar followed by urls done, matches, errors and requests per
+// second, until it is stopped
+type progress struct {
+	sc    *search.Scanner
+	w     io.Writer
+	start time.Time
+	done  chan struct{}
+	wg    sync.WaitGroup
+}
+
+// startProgress draws the status to w every interval
+func startProgress(w io.Writer, sc *search.Scanner, interval time.Duration) *progress {
+	p := &progress{sc: sc, w: w, start: time.Now(), done: make(chan struct{})}
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				fmt.Fprint(p.w, "\r"+p.line(time.Since(p.start)))
+			case <-p.done:
+				return
+			}
+		}
+	}()
+	return p
+}
+
+// stop draws the final status and ends the line
+func (p *progress) stop() {
+	close(p.done)
+	p.wg.Wait()
+	fmt.Fprintln(p.w, "\r"+p.line(time.Since(p.start)))
+}
+
+// line is the status after elapsed
+func (p *progress) line(elapsed time.Duration) string {
+	done, total := p.sc.Completed(), p.sc.Total()
+	// urls read from stdin aren't counted up front
+	if done > total {
+		total = done
+	}
+	var filled, percent int
+	if total > 0 {
+		filled, percent = barWidth*done/total, 100*done/total
+	}
+	rate := 0.0
+	if secs := elapsed.Seconds(); secs > 0 {
+		rate = float64(p.sc.Requests()) / secs
+	}
+	s := p.sc.Summary()
+	return fmt.Sprintf("[%s%s] %3d%% %d/%d urls, %d matched, %d errors, %.1f req/s",
+		strings.Repeat("#", filled), strings.Repeat(".", barWidth-filled), percent, done, total, s.Matched, s.Errored,
+		rate)
+}
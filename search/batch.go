@@ -0,0 +1,83 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// BatchError is returned by SearchBatch when urls failed, keyed by the url as it was passed in
+type BatchError struct {
+	Errors map[string]error
+}
+
+func (e *BatchError) Error() string {
+	urls := make([]string, 0, len(e.Errors))
+	for u := range e.Errors {
+		urls = append(urls, u)
+	}
+	sort.Strings(urls)
+
+	msgs := make([]string, 0, len(urls))
+	for _, u := range urls {
+		msgs = append(msgs, fmt.Sprintf("%s: %v", u, e.Errors[u]))
+	}
+	return fmt.Sprintf("%d urls failed: %s", len(e.Errors), strings.Join(msgs, "; "))
+}
+
+// SearchBatch searches every url for the scanner's keyword concurrently, bounded by the semaphore. By default every
+// url is tried and failures are returned together as a *BatchError. With FailFast the first error cancels the urls
+// still running or waiting and is returned as is. To time box a batch pass a ctx with a deadline, once it passes the
+// urls in flight are canceled, ctx.Err() is returned and Results holds whatever was found in time
+func (sc *Scanner) SearchBatch(parent context.Context, urls []string) error {
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mxt      sync.Mutex
+		errs     = make(map[string]error)
+		firstErr error
+	)
+	for _, u := range urls {
+		wg.Add(1)
+		go func(u string) {
+			defer wg.Done()
+			defer sc.markCompleted(1)
+
+			if ctx.Err() != nil {
+				return
+			}
+
+			err := sc.search(ctx, u, sc.kw)
+			if err == nil {
+				return
+			}
+
+			mxt.Lock()
+			defer mxt.Unlock()
+			if sc.FailFast {
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				return
+			}
+			errs[u] = err
+		}(u)
+	}
+	wg.Wait()
+
+	if err := parent.Err(); err != nil {
+		return err
+	}
+	if firstErr != nil {
+		return firstErr
+	}
+	if len(errs) > 0 {
+		return &BatchError{Errors: errs}
+	}
+	return nil
+}
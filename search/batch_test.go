@@ -0,0 +1,90 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func slowServer(delay time.Duration) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(delay):
+		}
+		fmt.Fprint(w, "<p>sign up</p>")
+	}))
+}
+
+func TestSearchBatchCollectsErrors(t *testing.T) {
+	ts := slowServer(0)
+	defer ts.Close()
+
+	sc := NewScanner(4, 0, false, "sign up")
+	err := sc.SearchBatch(context.Background(), []string{ts.URL + "/a", "", ts.URL + "/b"})
+
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("expected a BatchError got %v", err)
+	}
+	if len(batchErr.Errors) != 1 || batchErr.Errors[""] != ErrURLEmpty {
+		t.Errorf("unexpected errors %v", batchErr.Errors)
+	}
+
+	if len(sc.Results) != 2 {
+		t.Errorf("the good urls should still be searched, got %d results", len(sc.Results))
+	}
+}
+
+func TestSearchBatchFailFast(t *testing.T) {
+	ts := slowServer(5 * time.Second)
+	defer ts.Close()
+
+	sc := NewScanner(4, 0, false, "sign up")
+	sc.FailFast = true
+
+	start := time.Now()
+	err := sc.SearchBatch(context.Background(), []string{ts.URL + "/a", ts.URL + "/b", "", ts.URL + "/c"})
+	if err != ErrURLEmpty {
+		t.Fatalf("expected ErrURLEmpty got %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("the slow urls should have been canceled, took %s", elapsed)
+	}
+
+	if len(sc.Results) != 0 {
+		t.Errorf("no slow url should have finished, got %d results", len(sc.Results))
+	}
+}
+
+func TestSearchBatchDeadline(t *testing.T) {
+	slow := slowServer(5 * time.Second)
+	defer slow.Close()
+	fast := slowServer(0)
+	defer fast.Close()
+
+	urls := []string{fast.URL + "/a", slow.URL + "/a", fast.URL + "/b", slow.URL + "/b", slow.URL + "/c"}
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	sc := NewScanner(len(urls), 0, false, "sign up")
+	start := time.Now()
+	err := sc.SearchBatch(ctx, urls)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the deadline to be returned, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected in flight urls to be canceled at the deadline, took %v", elapsed)
+	}
+
+	found := sc.Results.MatchingURLs()
+	if len(found) != 2 {
+		t.Errorf("expected the fast urls as partial results, got %v", found)
+	}
+}
@@ -0,0 +1,37 @@
+package search
+
+// resultBuffer holds results for one goroutine and adds them to Results in batches of ResultBatchSize, it is not safe
+// for concurrent use
+type resultBuffer struct {
+	sc  *Scanner
+	buf Results
+}
+
+func (sc *Scanner) newResultBuffer() *resultBuffer {
+	return &resultBuffer{sc: sc}
+}
+
+// save is saveResult with the append to Results batched
+func (b *resultBuffer) save(r Result) {
+	sc := b.sc
+	if sc.ResultBatchSize <= 1 || sc.DiscardResults {
+		sc.saveResult(r)
+		return
+	}
+
+	sc.logResult(r)
+	b.buf = append(b.buf, r)
+	if len(b.buf) >= sc.ResultBatchSize {
+		b.flush()
+	}
+	sc.notify(r)
+}
+
+// flush adds whatever is held to Results
+func (b *resultBuffer) flush() {
+	if len(b.buf) == 0 {
+		return
+	}
+	b.sc.appendResults(b.buf...)
+	b.buf = b.buf[:0]
+}
@@ -0,0 +1,53 @@
+package search
+
+import "testing"
+
+func TestResultBuffer(t *testing.T) {
+	sc := NewScanner(1, 0, false, "")
+	sc.ResultBatchSize = 3
+	sc.ExpectedResults = 10
+
+	var seen int
+	sc.OnResult = func(Result) { seen++ }
+
+	buf := sc.newResultBuffer()
+	for i := 0; i < 4; i++ {
+		buf.save(Result{URL: "http://example.com"})
+	}
+	if len(sc.Results) != 3 || seen != 4 {
+		t.Errorf("expected one batch of 3 saved and every result passed on, got %d saved and %d seen", len(sc.Results), seen)
+	}
+	if cap(sc.Results) != 10 {
+		t.Errorf("expected room for the expected results, got cap %d", cap(sc.Results))
+	}
+
+	buf.flush()
+	if len(sc.Results) != 4 {
+		t.Errorf("expected the rest to be saved on flush, got %d", len(sc.Results))
+	}
+}
+
+func benchmarkSave(b *testing.B, batch, expected int) {
+	sc := NewScanner(1, 0, false, "")
+	sc.ResultBatchSize = batch
+	if expected > 0 {
+		sc.ExpectedResults = b.N
+	}
+	r := Result{URL: "http://example.com", Keyword: "sign up", Found: true}
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		buf := sc.newResultBuffer()
+		for pb.Next() {
+			buf.save(r)
+		}
+		buf.flush()
+	})
+}
+
+// every variant still takes the summary's lock per result, they differ in how often the Results lock is taken
+func BenchmarkSaveResult(b *testing.B) {
+	b.Run("locked", func(b *testing.B) { benchmarkSave(b, 0, 0) })
+	b.Run("prealloc", func(b *testing.B) { benchmarkSave(b, 0, 1) })
+	b.Run("batched", func(b *testing.B) { benchmarkSave(b, 64, 1) })
+}
@@ -0,0 +1,16 @@
+package search
+
+import "github.com/PuerkitoBio/goquery"
+
+// canonicalURL returns the page's <link rel="canonical"> resolved against pageURL, empty when there isn't one
+func canonicalURL(doc *goquery.Document, pageURL string) string {
+	href, ok := doc.Find(`link[rel="canonical"]`).First().Attr("href")
+	if !ok || href == "" {
+		return ""
+	}
+	canonical, err := resolveURL(pageURL, href)
+	if err != nil {
+		return ""
+	}
+	return canonical
+}
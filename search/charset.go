@@ -0,0 +1,30 @@
+package search
+
+import (
+	"io"
+	"net/http"
+
+	"golang.org/x/net/html/charset"
+)
+
+// transcode decodes a page served in another charset, such as ISO-8859-1 or Shift_JIS, to utf-8 when
+// TranscodeCharset is set. The charset comes from a byte order mark, the Content-Type header or a meta tag, in that
+// order, and a page declaring none that isn't valid utf-8 is read as windows-1252. Pdfs are left alone
+func (sc *Scanner) transcode(res *http.Response, body []byte) ([]byte, error) {
+	if !sc.TranscodeCharset || isPDF(res, body) {
+		return body, nil
+	}
+	enc, name, _ := charset.DetermineEncoding(body, res.Header.Get("Content-Type"))
+	if name == "utf-8" {
+		return body, nil
+	}
+	return enc.NewDecoder().Bytes(body)
+}
+
+// transcodeReader is transcode for a body that is streamed, the charset is worked out from the first 1024 bytes
+func (sc *Scanner) transcodeReader(res *http.Response, r io.Reader) (io.Reader, error) {
+	if !sc.TranscodeCharset {
+		return r, nil
+	}
+	return charset.NewReader(r, res.Header.Get("Content-Type"))
+}
@@ -0,0 +1,51 @@
+package search
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTranscodeCharset(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/latin1":
+			w.Header().Set("Content-Type", "text/html; charset=ISO-8859-1")
+			w.Write([]byte("<html><body><p>Caf\xe9 cr\xe8me</p></body></html>"))
+		case "/sjis":
+			// 日本 in Shift_JIS, declared only in the page
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<html><head><meta charset="shift_jis"></head><body><p>` + "\x93\xfa\x96\x7b" + `</p></body></html>`))
+		}
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		path, keyword string
+	}{
+		{"/latin1", "café crème"},
+		{"/sjis", "日本"},
+	}
+	for _, stream := range []bool{false, true} {
+		for _, test := range tests {
+			sc := NewScanner(1, 0, false, test.keyword)
+			sc.StopOnFirstMatch = stream
+			if err := sc.Search(ts.URL + test.path); err != nil {
+				t.Fatal(err)
+			}
+			if sc.Results[0].Found {
+				t.Errorf("%s: expected no match against the raw bytes", test.path)
+			}
+
+			sc = NewScanner(1, 0, false, test.keyword)
+			sc.StopOnFirstMatch = stream
+			sc.TranscodeCharset = true
+			if err := sc.Search(ts.URL + test.path); err != nil {
+				t.Fatal(err)
+			}
+			if !sc.Results[0].Found {
+				t.Errorf("%s stream %v: expected %q to match once transcoded", test.path, stream, test.keyword)
+			}
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package search
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// ContextMode decides what is saved as the Context of a match
+type ContextMode int
+
+const (
+	// ContextTag is the default, the html tag surrounding the match sliced out with a regex
+	ContextTag ContextMode = iota
+	// ContextOuterHTML is the outer html of the innermost element whose text contains the match
+	ContextOuterHTML
+	// ContextSentence is the sentence of the page text holding the match, sentences end at a . ! or ? followed by
+	// whitespace
+	ContextSentence
+)
+
+const (
+	// SourceRaw is a match in the raw html that isn't only in an attribute, e.g. in the text, a tag or a script
+	SourceRaw = "raw"
+	// SourceAttribute is a match in the raw html only found in attribute values, like an alt or href
+	SourceAttribute = "attribute"
+	// SourceText is a match in the page text, see MatchText
+	SourceText = "text"
+	// SourcePhrase is a match in the whitespace collapsed page text, see MatchPhrase
+	SourcePhrase = "phrase"
+)
+
+// rawSource tells a raw match that only sits in attribute values apart from any other
+func rawSource(p *page, re *regexp.Regexp) string {
+	if re.MatchString(p.pageText()) {
+		return SourceRaw
+	}
+
+	inAttr := false
+	p.document().Find("*").EachWithBreak(func(i int, s *goquery.Selection) bool {
+		for _, a := range s.Get(0).Attr {
+			if re.MatchString(a.Val) {
+				inAttr = true
+				return false
+			}
+		}
+		return true
+	})
+	if inAttr {
+		return SourceAttribute
+	}
+	return SourceRaw
+}
+
+// Match is a single occurrence of the keyword in the page text
+type Match struct {
+	// Text is the text that matched the keyword
+	Text string `json:"text"`
+	// Path is the css path to the element holding the match e.g html>body>div.content>p:nth-of-type(3)
+	Path string `json:"path"`
+}
+
+// Matches is every occurrence of the keyword in the page text
+type Matches []Match
+
+// matchingElement walks down from body to the innermost element whose text matches re, the selection is empty when
+// the match isn't in the page text (an attribute for example)
+func matchingElement(doc *goquery.Document, re *regexp.Regexp) *goquery.Selection {
+	sel := doc.Find("body")
+	if !re.MatchString(sel.Text()) {
+		return sel.Slice(0, 0)
+	}
+
+	for {
+		child := sel.Children().FilterFunction(func(i int, s *goquery.Selection) bool {
+			return re.MatchString(s.Text())
+		}).First()
+		if child.Length() == 0 {
+			return sel
+		}
+		sel = child
+	}
+}
+
+// outerHTMLContext returns the outer html of the innermost element containing the match
+func outerHTMLContext(doc *goquery.Document, re *regexp.Regexp) (string, bool) {
+	sel := matchingElement(doc, re)
+	if sel.Length() == 0 {
+		return "", false
+	}
+
+	h, err := goquery.OuterHtml(sel)
+	if err != nil {
+		return "", false
+	}
+	return h, true
+}
+
+// domMatches finds every innermost element whose text matches re and records its css path
+func domMatches(doc *goquery.Document, re *regexp.Regexp) (matches Matches) {
+	doc.Find("body, body *").Each(func(i int, s *goquery.Selection) {
+		if !re.MatchString(s.Text()) {
+			return
+		}
+
+		inner := s.Children().FilterFunction(func(i int, c *goquery.Selection) bool {
+			return re.MatchString(c.Text())
+		})
+		if inner.Length() > 0 {
+			return
+		}
+
+		for _, m := range re.FindAllString(s.Text(), -1) {
+			matches = append(matches, Match{Text: m, Path: cssPath(s.Get(0))})
+		}
+	})
+	return
+}
+
+// cssPath builds a selector from the root down to n, classes are added and :nth-of-type is used when an element has
+// siblings with the same tag
+func cssPath(n *html.Node) string {
+	var parts []string
+	for ; n != nil && n.Type == html.ElementNode; n = n.Parent {
+		part := n.Data
+		for _, a := range n.Attr {
+			if a.Key == "class" && strings.TrimSpace(a.Val) != "" {
+				part += "." + strings.Join(strings.Fields(a.Val), ".")
+			}
+		}
+
+		index, same := 0, 0
+		if n.Parent != nil {
+			for sib := n.Parent.FirstChild; sib != nil; sib = sib.NextSibling {
+				if sib.Type != html.ElementNode || sib.Data != n.Data {
+					continue
+				}
+				same++
+				if sib == n {
+					index = same
+				}
+			}
+		}
+		if same > 1 {
+			part += fmt.Sprintf(":nth-of-type(%d)", index)
+		}
+
+		parts = append([]string{part}, parts...)
+	}
+	return strings.Join(parts, ">")
+}
+
+// isSentenceEnd reports whether text[i] ends a sentence, a . ! or ? at the end of the text or before whitespace so
+// that numbers and domains like 1.5 or example.com don't split sentences
+func isSentenceEnd(text string, i int) bool {
+	switch text[i] {
+	case '.', '!', '?':
+		return i+1 == len(text) || strings.ContainsRune(" \t\n\r", rune(text[i+1]))
+	}
+	return false
+}
+
+// sentenceContext is the sentence of text that holds the match at loc, from the end of the previous sentence, or the
+// start of the text, up to and including the punctuation that ends it, or the end of the text
+func sentenceContext(text string, loc []int) string {
+	start := loc[0]
+	for start > 0 && !isSentenceEnd(text, start-1) {
+		start--
+	}
+	end := loc[1]
+	for end < len(text) && !isSentenceEnd(text, end-1) {
+		end++
+	}
+	return strings.TrimSpace(text[start:end])
+}
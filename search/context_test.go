@@ -0,0 +1,139 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOuterHTMLContext(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><div class="content"><p>intro</p><p class="cta">Please <b>sign up</b> today</p></div></body></html>`)
+	}))
+	defer ts.Close()
+
+	sc := NewScanner(1, 0, false, "")
+	sc.ContextMode = ContextOuterHTML
+	res, err := sc.Match(context.Background(), ts.URL, "sign up")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `<b>sign up</b>`
+	if res.Context != want {
+		t.Errorf("expected %s got %v", want, res.Context)
+	}
+
+	res, err = sc.Match(context.Background(), ts.URL, "today")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h, _ := res.Context.(string)
+	if !strings.HasPrefix(h, `<p class="cta">`) || !strings.HasSuffix(h, `</p>`) {
+		t.Errorf("the context should be the enclosing p tag, got %s", h)
+	}
+}
+
+func TestDOMPath(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><div class="content main"><p>one</p><p>two</p><p>find <i>me</i> here</p></div></body></html>`)
+	}))
+	defer ts.Close()
+
+	sc := NewScanner(1, 0, false, "")
+	sc.MatchMode = MatchText
+	res, err := sc.Match(context.Background(), ts.URL, "here")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Matches == nil || len(*res.Matches) != 1 {
+		t.Fatalf("expected one match got %+v", res.Matches)
+	}
+
+	want := "html>body>div.content.main>p:nth-of-type(3)"
+	if m := (*res.Matches)[0]; m.Path != want || m.Text != "here" {
+		t.Errorf("expected path %s got %+v", want, m)
+	}
+
+	sc.MatchMode = MatchRaw
+	res, err = sc.Match(context.Background(), ts.URL, "here")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Matches != nil {
+		t.Errorf("raw html mode should not record dom paths, got %+v", res.Matches)
+	}
+}
+
+func TestContextWhitespace(t *testing.T) {
+	body := []byte("<html><body><p class=\"cta\">\t please   sign up\t\ttoday  </p></body></html>")
+	kw := mustKeyword("sign up")
+
+	sc := NewScanner(1, 0, false, "")
+	if r := sc.evaluate("http://example.com", kw, body); r.Context != `<p class="cta"> please sign up today </p>` {
+		t.Errorf("expected collapsed whitespace, got %q", r.Context)
+	}
+
+	sc.MatchMode = MatchText
+	if r := sc.evaluate("http://example.com", kw, body); r.Context != "please sign up today" {
+		t.Errorf("expected collapsed and trimmed text, got %q", r.Context)
+	}
+
+	sc.RawContext = true
+	if r := sc.evaluate("http://example.com", kw, body); r.Context != "please   sign up\t\ttoday" {
+		t.Errorf("expected the raw snippet, got %q", r.Context)
+	}
+}
+
+func TestSentenceContext(t *testing.T) {
+	body := []byte(`<html><body><p>Welcome to example.com. It costs 1.5 dollars! Please <b>sign up</b> today, it is free.
+	Questions?</p><p>Sign up now</p></body></html>`)
+
+	sc := NewScanner(1, 0, false, "")
+	sc.ContextMode = ContextSentence
+	for _, test := range []struct {
+		keyword, want string
+	}{
+		{"sign up", "Please sign up today, it is free."},
+		{"welcome", "Welcome to example.com."},
+		{"dollars", "It costs 1.5 dollars!"},
+		{"questions", "Questions?"},
+		{"now", "Sign up now"},
+		{`free\.\s+questions`, "Please sign up today, it is free. Questions?"},
+	} {
+		r := sc.evaluate("http://example.com", mustKeyword(test.keyword), body)
+		if r.Context != test.want {
+			t.Errorf("%s: expected %q got %q", test.keyword, test.want, r.Context)
+		}
+	}
+}
+
+func TestMatchSource(t *testing.T) {
+	body := []byte(`<html><body><p>please sign up</p><img alt="free trial" src="/a.png"><script>var promo = "coupon"</script></body></html>`)
+
+	sc := NewScanner(1, 0, false, "")
+	for _, test := range []struct {
+		mode    MatchMode
+		keyword string
+		want    string
+	}{
+		{MatchRaw, "sign up", SourceRaw},
+		{MatchRaw, "free trial", SourceAttribute},
+		{MatchRaw, "coupon", SourceRaw},
+		{MatchRaw, "missing", ""},
+		{MatchText, "sign up", SourceText},
+		{MatchText, "free trial", ""},
+		{MatchPhrase, "please sign", SourcePhrase},
+	} {
+		sc.MatchMode = test.mode
+		r := sc.evaluate("http://example.com", mustKeyword(test.keyword), body)
+		if r.MatchSource != test.want {
+			t.Errorf("mode %d %q: expected source %q got %q", test.mode, test.keyword, test.want, r.MatchSource)
+		}
+	}
+}
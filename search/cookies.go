@@ -0,0 +1,113 @@
+package search
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnableCookies keeps the cookies pages set and sends them back on later requests, so consent walls and logged in
+// areas can be searched. A nil jar uses an empty in memory one. Call it before searching, the cookies are shared by
+// every search on the scanner
+func (sc *Scanner) EnableCookies(jar http.CookieJar) error {
+	if jar == nil {
+		var err error
+		if jar, err = cookiejar.New(nil); err != nil {
+			return err
+		}
+	}
+	sc.Client.Jar = jar
+	return nil
+}
+
+// ParseCookiesFile reads cookies from a Netscape cookies.txt, the format browser extensions and curl export. Lines
+// prefixed with #HttpOnly_ are HttpOnly cookies, other comments and blank lines are skipped. A domain that includes
+// subdomains keeps its leading dot, otherwise the cookie is host only
+func ParseCookiesFile(r io.Reader) ([]*http.Cookie, error) {
+	var cookies []*http.Cookie
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimRight(s.Text(), "\r")
+		httpOnly := strings.HasPrefix(line, "#HttpOnly_")
+		if httpOnly {
+			line = strings.TrimPrefix(line, "#HttpOnly_")
+		}
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("cookies line %d: expected 7 tab separated fields, got %d", n, len(fields))
+		}
+		expires, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("cookies line %d: bad expiry %q", n, fields[4])
+		}
+
+		c := &http.Cookie{
+			Domain:   fields[0],
+			Path:     fields[2],
+			Secure:   strings.EqualFold(fields[3], "TRUE"),
+			Name:     fields[5],
+			Value:    fields[6],
+			HttpOnly: httpOnly,
+		}
+		if !strings.EqualFold(fields[1], "TRUE") {
+			c.Domain = strings.TrimPrefix(c.Domain, ".")
+		}
+		// 0 is a session cookie
+		if expires > 0 {
+			c.Expires = time.Unix(expires, 0)
+		}
+		cookies = append(cookies, c)
+	}
+	return cookies, s.Err()
+}
+
+// LoadCookiesFile seeds the scanner's cookie jar from a Netscape cookies.txt, enabling cookies first when they
+// aren't. Cookies that have already expired are skipped
+func (sc *Scanner) LoadCookiesFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	cookies, err := ParseCookiesFile(f)
+	if err != nil {
+		return err
+	}
+	if sc.Client.Jar == nil {
+		if err := sc.EnableCookies(nil); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	for _, c := range cookies {
+		if !c.Expires.IsZero() && c.Expires.Before(now) {
+			continue
+		}
+		scheme := "http"
+		if c.Secure {
+			scheme = "https"
+		}
+		u := &url.URL{Scheme: scheme, Host: strings.TrimPrefix(c.Domain, "."), Path: c.Path}
+		// the jar only keeps the domain attribute of cookies that cover subdomains
+		if !strings.HasPrefix(c.Domain, ".") {
+			host := *c
+			host.Domain = ""
+			c = &host
+		}
+		sc.Client.Jar.SetCookies(u, []*http.Cookie{c})
+	}
+	return nil
+}
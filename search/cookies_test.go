@@ -0,0 +1,85 @@
+package search
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func cookieServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/consent" {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "ok", Path: "/"})
+		}
+		if c, err := r.Cookie("session"); err == nil && c.Value != "" {
+			fmt.Fprint(w, "<html><body><p>members area</p></body></html>")
+			return
+		}
+		fmt.Fprint(w, "<html><body><p>accept cookies</p></body></html>")
+	}))
+}
+
+func TestEnableCookies(t *testing.T) {
+	ts := cookieServer()
+	defer ts.Close()
+
+	sc := NewScanner(1, 0, false, "members area")
+	if err := sc.Search(ts.URL + "/page"); err != nil {
+		t.Fatal(err)
+	}
+	if err := sc.EnableCookies(nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"/consent", "/page"} {
+		if err := sc.Search(ts.URL + path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(sc.Results) != 3 || sc.Results[0].Found || !sc.Results[2].Found {
+		t.Errorf("expected /page to be found only once the consent cookie was set, got %+v", sc.Results)
+	}
+}
+
+func TestLoadCookiesFile(t *testing.T) {
+	ts := cookieServer()
+	defer ts.Close()
+
+	p := filepath.Join(t.TempDir(), "cookies.txt")
+	lines := "# Netscape HTTP Cookie File\n\n" +
+		"#HttpOnly_127.0.0.1\tFALSE\t/\tFALSE\t0\tsession\tabc\n" +
+		"127.0.0.1\tFALSE\t/\tFALSE\t1\told\tgone\n"
+	if err := ioutil.WriteFile(p, []byte(lines), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sc := NewScanner(1, 0, false, "members area")
+	if err := sc.LoadCookiesFile(p); err != nil {
+		t.Fatal(err)
+	}
+	if err := sc.Search(ts.URL + "/page"); err != nil {
+		t.Fatal(err)
+	}
+	if !sc.Results[0].Found {
+		t.Errorf("expected the cookie from the file to be sent")
+	}
+
+	cookies, err := ParseCookiesFile(strings.NewReader(lines + ".example.com\tTRUE\t/docs\tTRUE\t0\tlang\tfr\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cookies) != 3 || !cookies[0].HttpOnly || cookies[1].Expires.Unix() != 1 {
+		t.Fatalf("expected 3 cookies with the first HttpOnly and the second expired, got %+v", cookies)
+	}
+	if c := cookies[2]; c.Domain != ".example.com" || c.Path != "/docs" || !c.Secure || c.Value != "fr" {
+		t.Errorf("expected a secure subdomain cookie, got %+v", c)
+	}
+
+	if _, err := ParseCookiesFile(strings.NewReader("example.com\tFALSE\t/\n")); err == nil {
+		t.Errorf("expected a short line to error")
+	}
+}
@@ -0,0 +1,166 @@
+package search
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	log "github.com/marcsantiago/logger"
+)
+
+// crawlItem is a page waiting to be searched, root is the seed it was found from and bounds which links are followed
+type crawlItem struct {
+	URL  string
+	root string
+}
+
+// Crawl searches for the keyword breadth first from the seeds, following links on the same site up to levels deep.
+// Every level runs as its own stage fed through a channel bounded by LevelBuffer, so levels are worked on concurrently
+// and only about a level's width of urls is ever queued rather than the whole graph. The visited set does still grow
+// with every page seen. Fetches share the scanner's semaphore and the first error is returned once the crawl is done.
+// With RespectRobots links robots.txt disallows are saved as RobotsBlocked instead of followed, the seeds never are
+func (sc *Scanner) Crawl(ctx context.Context, seeds []string, keyword string, levels int) error {
+	kw, err := NewKeyword(keyword, KeywordOptions{})
+	if err != nil {
+		return err
+	}
+
+	buffer := sc.LevelBuffer
+	if buffer <= 0 {
+		buffer = cap(sc.Semaphore)
+	}
+
+	var (
+		visitedMxt sync.Mutex
+		visited    = make(map[string]bool)
+		errMxt     sync.Mutex
+		firstErr   error
+	)
+	firstVisit := func(URL string) bool {
+		visitedMxt.Lock()
+		defer visitedMxt.Unlock()
+		if visited[URL] {
+			return false
+		}
+		visited[URL] = true
+		return sc.firstSearch(URL, kw.raw)
+	}
+	fail := func(err error) {
+		errMxt.Lock()
+		if firstErr == nil {
+			firstErr = err
+		}
+		errMxt.Unlock()
+	}
+
+	in := make(chan crawlItem, buffer)
+	go func(in chan<- crawlItem) {
+		defer close(in)
+		for _, seed := range seeds {
+			URL, err := sc.normalize(seed)
+			if err != nil {
+				fail(err)
+				continue
+			}
+			if firstVisit(URL) {
+				in <- crawlItem{URL: URL, root: URL}
+			}
+		}
+	}(in)
+
+	var stages sync.WaitGroup
+	for level := 0; level <= levels; level++ {
+		var out chan crawlItem
+		if level < levels {
+			out = make(chan crawlItem, buffer)
+		}
+
+		stages.Add(1)
+		go func(in <-chan crawlItem, out chan<- crawlItem) {
+			defer stages.Done()
+			sc.crawlLevel(ctx, in, out, buffer, kw, firstVisit, fail)
+		}(in, out)
+		in = out
+	}
+	stages.Wait()
+
+	sc.markCompleted(len(seeds))
+	return firstErr
+}
+
+// crawlLevel works through one level, at most buffer pages of the level are in flight. Discovered links are sent to
+// out after the semaphores are released so a full next level can never starve the fetches it is waiting on
+func (sc *Scanner) crawlLevel(ctx context.Context, in <-chan crawlItem, out chan<- crawlItem, buffer int, kw *Keyword,
+	firstVisit func(string) bool, fail func(error)) {
+
+	if out != nil {
+		defer close(out)
+	}
+
+	var wg sync.WaitGroup
+	inFlight := make(Semaphore, buffer)
+	for item := range in {
+		// once ctx is done the level still drains in so the stage feeding it can finish
+		if err := inFlight.loadContext(ctx); err != nil {
+			sc.fail(Result{URL: item.URL, Keyword: kw.raw, SeedURL: item.root}, err)
+			sc.forgetSearches([]string{item.URL}, kw.raw)
+			fail(err)
+			continue
+		}
+		wg.Add(1)
+		go func(item crawlItem) {
+			defer wg.Done()
+			defer inFlight.release()
+
+			release, err := sc.acquireContext(ctx, item.URL)
+			if err != nil {
+				sc.fail(Result{URL: item.URL, Keyword: kw.raw, SeedURL: item.root}, err)
+				sc.forgetSearches([]string{item.URL}, kw.raw)
+				fail(err)
+				return
+			}
+			if sc.Logging {
+				log.Info(logkey, "looking for keyword", "keyword", kw.raw, "url", item.URL)
+			}
+			sc.robotsFirst(ctx, item.URL)
+			start := time.Now()
+			res, body, URL, err := sc.fetchResponse(ctx, item.URL)
+			if err != nil {
+				release()
+				sc.fail(Result{URL: item.URL, Keyword: kw.raw, SeedURL: item.root}, err)
+				sc.forgetSearches([]string{item.URL}, kw.raw)
+				fail(err)
+				return
+			}
+			elapsed := time.Since(start)
+			r := sc.evaluate(URL, kw, body)
+			r.Duration = elapsed
+			sc.describeResponse(&r, res)
+			r.SeedURL = item.root
+			if r.URL != URL && !firstVisit(r.URL) {
+				// the page's canonical url has already been searched
+				release()
+				return
+			}
+			sc.saveResult(r)
+
+			var links, blocked []string
+			if out != nil {
+				links, blocked = sc.robotsAllowed(ctx, sc.extractLinks(body, item.root, 0)[1:])
+			}
+			release()
+
+			for _, link := range blocked {
+				if firstVisit(link) {
+					sc.saveResult(Result{URL: link, Keyword: kw.raw, RobotsBlocked: true, SeedURL: item.root})
+				}
+			}
+			for _, link := range links {
+				if firstVisit(link) {
+					out <- crawlItem{URL: link, root: item.root}
+				}
+			}
+		}(item)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,150 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// wideServer serves a tree where every page links to width children, pages are named by their path of child indexes
+func wideServer(width int) (*httptest.Server, func() map[string]int) {
+	var (
+		ts   *httptest.Server
+		mxt  sync.Mutex
+		hits = make(map[string]int)
+	)
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mxt.Lock()
+		hits[r.URL.Path]++
+		mxt.Unlock()
+
+		base := strings.TrimSuffix(r.URL.Path, "/")
+		fmt.Fprint(w, "<html><body><p>sign up</p>")
+		for i := 0; i < width; i++ {
+			fmt.Fprintf(w, `<a href="%s%s/%d">child</a>`, ts.URL, base, i)
+		}
+		fmt.Fprint(w, "</body></html>")
+	}))
+	return ts, func() map[string]int {
+		mxt.Lock()
+		defer mxt.Unlock()
+		return hits
+	}
+}
+
+func TestCrawl(t *testing.T) {
+	ts, hits := wideServer(5)
+	defer ts.Close()
+
+	sc := NewScanner(3, 0, false, "")
+	sc.LevelBuffer = 2
+	if err := sc.Crawl(context.Background(), []string{ts.URL, ts.URL}, "sign up", 2); err != nil {
+		t.Fatal(err)
+	}
+
+	// 1 root + 5 children + 25 grandchildren
+	if len(sc.Results) != 31 {
+		t.Errorf("expected 31 results got %d", len(sc.Results))
+	}
+
+	for path, n := range hits() {
+		if n != 1 {
+			t.Errorf("%s fetched %d times", path, n)
+		}
+	}
+
+	if sc.Completed() != 2 {
+		t.Errorf("both seeds should count as completed, got %d", sc.Completed())
+	}
+}
+
+func BenchmarkCrawlWide(b *testing.B) {
+	ts, _ := wideServer(40)
+	defer ts.Close()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		sc := NewScanner(8, 0, false, "")
+		sc.DiscardResults = true
+		if err := sc.Crawl(context.Background(), []string{ts.URL}, "sign up", 2); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestSeedURL(t *testing.T) {
+	ts, _ := wideServer(3)
+	defer ts.Close()
+	seed, err := NormalizeURL(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	search := NewScanner(2, 10, false, "sign up")
+	if err := search.Search(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	crawl := NewScanner(2, 0, false, "")
+	if err := crawl.Crawl(context.Background(), []string{ts.URL}, "sign up", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, results := range map[string]Results{"Search": search.Results, "Crawl": crawl.Results} {
+		if len(results) != 4 {
+			t.Errorf("%s: expected the seed and its 3 children, got %d results", name, len(results))
+		}
+		for _, r := range results {
+			if r.SeedURL != seed {
+				t.Errorf("%s: expected %s to carry seed %s, got %q", name, r.URL, seed, r.SeedURL)
+			}
+		}
+	}
+}
+
+func TestRespectCanonical(t *testing.T) {
+	var (
+		ts   *httptest.Server
+		mxt  sync.Mutex
+		hits = make(map[string]int)
+	)
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mxt.Lock()
+		hits[r.URL.Path]++
+		mxt.Unlock()
+
+		switch r.URL.Path {
+		case "/a", "/b":
+			fmt.Fprintf(w, `<html><head><link rel="canonical" href="/article"></head><body><p>sign up</p><a href="%s/article">article</a></body></html>`, ts.URL)
+		case "/article":
+			fmt.Fprint(w, "<html><body><p>sign up</p></body></html>")
+		default:
+			fmt.Fprintf(w, `<html><body><a href="%[1]s/a">a</a><a href="%[1]s/b">b</a></body></html>`, ts.URL)
+		}
+	}))
+	defer ts.Close()
+
+	search := NewScanner(2, 10, false, "sign up")
+	search.RespectCanonical = true
+	if err := search.Search(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	crawl := NewScanner(2, 0, false, "")
+	crawl.RespectCanonical = true
+	if err := crawl.Crawl(context.Background(), []string{ts.URL}, "sign up", 2); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, sc := range map[string]*Scanner{"Search": search, "Crawl": crawl} {
+		urls := sc.Results.MatchingURLs()
+		if len(urls) != 1 || urls[0] != ts.URL+"/article" {
+			t.Errorf("%s: expected the two pages to be saved once under their canonical url, got %v", name, urls)
+		}
+	}
+	if hits["/article"] != 0 {
+		t.Errorf("the canonical url should count as visited and not be fetched, got %d fetches", hits["/article"])
+	}
+}
@@ -0,0 +1,31 @@
+package search
+
+import (
+	"net/http"
+
+	log "github.com/marcsantiago/logger"
+)
+
+// maxTraceOffsets caps how many match offsets a trace lists
+const maxTraceOffsets = 10
+
+// traceFetch logs what was fetched for URL when DebugMatch is set
+func (sc *Scanner) traceFetch(URL string, res *http.Response, body []byte) {
+	if !sc.DebugMatch {
+		return
+	}
+	log.Info(logkey, "match trace: fetched", "url", URL, "status", res.StatusCode,
+		"content type", res.Header.Get("Content-Type"), "body bytes", len(body),
+		"pdf text extracted", sc.PDFExtractor != nil && isPDF(res, body), "body transformed", sc.BodyTransform != nil)
+}
+
+// traceMatch logs how the keyword was matched against subject, the raw body or the page text, when DebugMatch is set
+func (sc *Scanner) traceMatch(URL string, kw *Keyword, subject string, found bool) {
+	if !sc.DebugMatch {
+		return
+	}
+	offsets := kw.searchRegex.FindAllStringIndex(subject, maxTraceOffsets)
+	log.Info(logkey, "match trace: matched", "url", URL, "regex", kw.searchRegex.String(),
+		"text extracted", sc.MatchMode != MatchRaw, "html unescaped", sc.MatchMode != MatchRaw && sc.HTMLUnescape,
+		"bytes matched", len(subject), "found", found, "offsets", offsets)
+}
@@ -0,0 +1,52 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	log "github.com/marcsantiago/logger"
+)
+
+func TestDebugMatch(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, "<html><body><p>log in</p></body></html>")
+	}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stdout)
+
+	sc := NewScanner(1, 0, false, "")
+	sc.DebugMatch = true
+	res, err := sc.Match(context.Background(), ts.URL, "sign up")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Found {
+		t.Fatal("the keyword should not be found")
+	}
+
+	out := buf.String()
+	for _, want := range []string{"match trace: fetched", "text/html; charset=utf-8", "match trace: matched", "(?i)sign up", "found='false'"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected the trace to contain %q, got %s", want, out)
+		}
+	}
+
+	buf.Reset()
+	sc.DebugMatch = false
+	if _, err := sc.Match(context.Background(), ts.URL, "sign up"); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected no trace without DebugMatch, got %s", buf.String())
+	}
+}
@@ -0,0 +1,101 @@
+package search
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+
+	log "github.com/marcsantiago/logger"
+)
+
+// urlSet holds the urls already searched for DedupURLs, keyed by dedupKey and the keyword
+type urlSet struct {
+	mxt  sync.Mutex
+	seen map[string]bool
+}
+
+// add records key and reports whether it was new
+func (s *urlSet) add(key string) bool {
+	s.mxt.Lock()
+	defer s.mxt.Unlock()
+
+	if s.seen == nil {
+		s.seen = make(map[string]bool)
+	}
+	if s.seen[key] {
+		return false
+	}
+	s.seen[key] = true
+	return true
+}
+
+func (s *urlSet) remove(key string) {
+	s.mxt.Lock()
+	defer s.mxt.Unlock()
+	delete(s.seen, key)
+}
+
+// dedupKey is the form urls are compared in for DedupURLs, on top of normalization the scheme and host are lower
+// cased and the default port, the fragment and a trailing slash are dropped
+func dedupKey(URL string) string {
+	u, err := url.Parse(URL)
+	if err != nil {
+		return URL
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	if port := u.Port(); (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		u.Host = u.Hostname()
+	}
+	u.Fragment = ""
+	u.RawFragment = ""
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	u.RawPath = strings.TrimSuffix(u.RawPath, "/")
+	return u.String()
+}
+
+// firstSearch claims the normalized URL for keyword and reports whether it wasn't already, it is always true without
+// DedupURLs. A claim whose fetch fails is given back with forgetSearches
+func (sc *Scanner) firstSearch(URL, keyword string) bool {
+	if !sc.DedupURLs {
+		return true
+	}
+	if sc.searched.add(keyword + "\x00" + dedupKey(URL)) {
+		return true
+	}
+	if sc.Logging {
+		log.Info(logkey, "skipping url already searched", "url", URL)
+	}
+	return false
+}
+
+// forgetSearches gives back the claims firstSearch made on urls so they are searched again when they come up
+func (sc *Scanner) forgetSearches(urls []string, keyword string) {
+	if !sc.DedupURLs {
+		return
+	}
+	for _, u := range urls {
+		sc.searched.remove(keyword + "\x00" + dedupKey(u))
+	}
+}
+
+// forgetOnError is forgetSearches for URL when *err is set, for deferring
+func (sc *Scanner) forgetOnError(err *error, URL, keyword string) {
+	if *err != nil {
+		sc.forgetSearches([]string{URL}, keyword)
+	}
+}
+
+// unsearched keeps the urls that firstSearch lets through
+func (sc *Scanner) unsearched(urls []string, keyword string) []string {
+	if !sc.DedupURLs {
+		return urls
+	}
+	kept := urls[:0:0]
+	for _, u := range urls {
+		if sc.firstSearch(u, keyword) {
+			kept = append(kept, u)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,154 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestDedupKey(t *testing.T) {
+	tests := []struct {
+		a, b string
+	}{
+		{"http://Example.com/a/", "http://example.com/a"},
+		{"http://example.com:80/a#top", "http://example.com/a"},
+		{"https://example.com:443", "https://example.com/"},
+	}
+	for _, tt := range tests {
+		if dedupKey(tt.a) != dedupKey(tt.b) {
+			t.Errorf("expected %s and %s to be the same url, got %s and %s", tt.a, tt.b, dedupKey(tt.a), dedupKey(tt.b))
+		}
+	}
+	if dedupKey("http://example.com/a") == dedupKey("https://example.com/a") {
+		t.Error("expected http and https to be different urls")
+	}
+}
+
+func TestDedupURLs(t *testing.T) {
+	var (
+		mxt  sync.Mutex
+		hits = make(map[string]int)
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mxt.Lock()
+		hits[r.URL.Path]++
+		mxt.Unlock()
+		fmt.Fprint(w, "<p>sign up</p>")
+	}))
+	defer ts.Close()
+
+	for _, dedup := range []bool{false, true} {
+		hits = make(map[string]int)
+		sc := NewScanner(4, 0, false, "sign up")
+		sc.DedupURLs = dedup
+		urls := []string{ts.URL + "/a", ts.URL + "/a/", ts.URL + "/a#top", ts.URL + "/b"}
+		if err := sc.SearchBatch(context.Background(), urls); err != nil {
+			t.Fatal(err)
+		}
+
+		want := 1
+		if !dedup {
+			want = 3
+		}
+		if got := hits["/a"] + hits["/a/"]; got != want {
+			t.Errorf("dedup %v: expected /a to be fetched %d times got %d", dedup, want, got)
+		}
+		if dedup && len(sc.Results) != 2 {
+			t.Errorf("expected a result for /a and /b got %d", len(sc.Results))
+		}
+	}
+
+	// the same url is still searched for another keyword
+	sc := NewScanner(4, 0, false, "sign up")
+	sc.DedupURLs = true
+	for _, kw := range []string{"sign up", "sign up", "log in"} {
+		k, err := NewKeyword(kw, KeywordOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := sc.SearchCompiled(ts.URL+"/c", k); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(sc.Results) != 2 {
+		t.Errorf("expected a result per keyword got %d", len(sc.Results))
+	}
+}
+
+func TestDedupURLsMethods(t *testing.T) {
+	var (
+		mxt  sync.Mutex
+		hits = make(map[string]int)
+	)
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mxt.Lock()
+		hits[r.URL.Path]++
+		n := hits[r.URL.Path]
+		mxt.Unlock()
+		if r.URL.Path == "/flaky" && n == 1 {
+			// drop the connection so the fetch fails
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+			return
+		}
+		fmt.Fprint(w, "<p>sign up</p>")
+	}))
+	// the client would otherwise resend the request dropped on a kept alive connection
+	ts.Config.SetKeepAlivesEnabled(false)
+	ts.Start()
+	defer ts.Close()
+
+	sc := NewScanner(4, 0, false, "sign up")
+	sc.DedupURLs = true
+	for i := 0; i < 2; i++ {
+		sc.Probe(ts.URL + "/probe")
+		sc.SearchMany(ts.URL+"/many", []string{"sign up", "log in"})
+		sc.SearchSeeds(context.Background(), []string{ts.URL + "/seeds"}, "sign up")
+	}
+	for _, path := range []string{"/probe", "/many", "/seeds"} {
+		if hits[path] != 1 {
+			t.Errorf("expected %s to be fetched once got %d", path, hits[path])
+		}
+	}
+
+	// the first fetch fails so the url isn't counted as searched
+	if err := sc.Search(ts.URL + "/flaky"); err == nil {
+		t.Fatal("expected the first fetch of /flaky to fail")
+	}
+	if err := sc.Search(ts.URL + "/flaky"); err != nil {
+		t.Fatal(err)
+	}
+	if hits["/flaky"] != 2 {
+		t.Errorf("expected /flaky to be fetched again after failing, fetched %d times", hits["/flaky"])
+	}
+}
+
+func TestDedupURLsDepth(t *testing.T) {
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<p>sign up</p><a href="%s/a/b/shared">shared</a>`, ts.URL)
+	}))
+	defer ts.Close()
+
+	// links are only followed below the seed, both seeds reach the shared page
+	sc := NewScanner(4, 4, false, "sign up")
+	sc.DedupURLs = true
+	for _, seed := range []string{"/a", "/a/b"} {
+		if err := sc.Search(ts.URL + seed); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	seen := 0
+	for _, r := range sc.Results {
+		if r.URL == ts.URL+"/a/b/shared" {
+			seen++
+		}
+	}
+	if seen != 1 {
+		t.Errorf("expected the page both seeds link to to be searched once got %d results for it", seen)
+	}
+}
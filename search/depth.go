@@ -0,0 +1,52 @@
+package search
+
+import (
+	"context"
+	"sync"
+
+	log "github.com/marcsantiago/logger"
+)
+
+// pageResult is the outcome of searching one of the urls found on a seed
+type pageResult struct {
+	r   Result
+	err error
+}
+
+// searchPages searches every url and returns the outcomes in the same order. The caller's semaphore slot works
+// through the urls and, with ParallelDepth, extra workers join for as long as free slots can be taken without
+// waiting, so a search never blocks on slots held by itself
+func (sc *Scanner) searchPages(ctx context.Context, urls []string, kw *Keyword) []pageResult {
+	pages := make([]pageResult, len(urls))
+	next := make(chan int, len(urls))
+	for i := range urls {
+		next <- i
+	}
+	close(next)
+
+	work := func() {
+		for i := range next {
+			if sc.Logging {
+				log.Info(logkey, "looking for keyword", "keyword", kw.raw, "url", urls[i])
+			}
+			pages[i].r, pages[i].err = sc.searchPage(ctx, urls[i], kw)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for n := 1; sc.ParallelDepth && n < len(urls); n++ {
+		release, ok := sc.tryAcquire(urls[n])
+		if !ok {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer release()
+			work()
+		}()
+	}
+	work()
+	wg.Wait()
+	return pages
+}
@@ -0,0 +1,71 @@
+package search
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParallelDepth(t *testing.T) {
+	for _, parallel := range []bool{false, true} {
+		var children gauge
+		var ts *httptest.Server
+		ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/" {
+				fmt.Fprintf(w, `<a href="%[1]s/a">a</a><a href="%[1]s/b">b</a><a href="%[1]s/c">c</a>`, ts.URL)
+				return
+			}
+			children.inc()
+			time.Sleep(50 * time.Millisecond)
+			children.dec()
+			fmt.Fprintf(w, "<p>sign up on %s</p>", r.URL.Path)
+		}))
+
+		sc := NewScanner(4, 4, false, "sign up")
+		sc.ParallelDepth = parallel
+		if err := sc.Search(ts.URL); err != nil {
+			t.Fatal(err)
+		}
+		ts.Close()
+
+		if parallel && children.max < 2 {
+			t.Errorf("expected the child pages to be fetched concurrently, at most %d were", children.max)
+		}
+		if !parallel && children.max != 1 {
+			t.Errorf("expected the child pages to be fetched one at a time, %d were", children.max)
+		}
+		if len(sc.Results) != 4 || sc.Results[1].URL != ts.URL+"/a" || sc.Results[3].URL != ts.URL+"/c" {
+			t.Errorf("expected results in the order the pages were found, got %+v", sc.Results)
+		}
+		if len(sc.Semaphore) != 0 {
+			t.Errorf("expected every slot to be released, %d still held", len(sc.Semaphore))
+		}
+	}
+}
+
+func TestParallelDepthSingleSlot(t *testing.T) {
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<a href="%[1]s/a">a</a><a href="%[1]s/b">b</a><p>sign up</p>`, ts.URL)
+	}))
+	defer ts.Close()
+
+	// the seed holds the only slot so the pages have to be searched on it rather than wait for another
+	sc := NewScanner(1, 3, false, "sign up")
+	sc.ParallelDepth = true
+	done := make(chan error, 1)
+	go func() { done <- sc.Search(ts.URL) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("search deadlocked waiting for a slot")
+	}
+	if len(sc.Results) != 3 {
+		t.Errorf("expected 3 results got %d", len(sc.Results))
+	}
+}
@@ -0,0 +1,21 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// WithDialContext replaces how the scanner opens connections, for example to reach services over a unix socket or
+// through a sidecar. Every request is dialed through dial whatever host the url names. It only works with the default
+// *http.Transport and replaces the dns cache if EnableDNSCache was called first
+func (sc *Scanner) WithDialContext(dial func(ctx context.Context, network, addr string) (net.Conn, error)) error {
+	t, ok := sc.Client.Transport.(*http.Transport)
+	if !ok {
+		return fmt.Errorf("custom dialer needs an *http.Transport, got %T", sc.Client.Transport)
+	}
+
+	t.DialContext = dial
+	return nil
+}
@@ -0,0 +1,59 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestWithDialContext(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "search.sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Skip("unix sockets not supported:", err)
+	}
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<html><body><p>sign up</p></body></html>")
+	}))
+	ts.Listener = l
+	ts.Start()
+	defer ts.Close()
+
+	var dialed []string
+	sc := NewScanner(1, 0, false, "sign up")
+	err = sc.WithDialContext(func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dialed = append(dialed, addr)
+		var d net.Dialer
+		return d.DialContext(ctx, "unix", sock)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sc.Search("http://sidecar.test"); err != nil {
+		t.Fatal(err)
+	}
+	if len(sc.Results) != 1 || !sc.Results[0].Found {
+		t.Errorf("expected the keyword to be found over the socket, got %+v", sc.Results)
+	}
+	if len(dialed) == 0 || dialed[0] != "sidecar.test:80" {
+		t.Errorf("expected the dialer to be handed the url's address, got %v", dialed)
+	}
+}
+
+func TestWithDialContextCustomTransport(t *testing.T) {
+	sc := NewScanner(1, 0, false, "")
+	sc.Client.Transport = roundTripper(nil)
+	if err := sc.WithDialContext(nil); err == nil {
+		t.Error("expected an error for a transport that can't take a dialer")
+	}
+}
+
+type roundTripper func(*http.Request) (*http.Response, error)
+
+func (f roundTripper) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
@@ -0,0 +1,65 @@
+package search
+
+import "sort"
+
+// Diff is what changed between two runs over the same urls, results are paired up by url and keyword
+type Diff struct {
+	// Matched are the new results found that weren't found before, or weren't there at all
+	Matched Results `json:"matched"`
+	// Unmatched are the new results no longer found that were found before
+	Unmatched Results `json:"unmatched"`
+	// Changed are found in both runs but with a different number of matches
+	Changed []CountChange `json:"changed"`
+}
+
+// CountChange is a result found in both runs with a different number of matches
+type CountChange struct {
+	Old Result `json:"old"`
+	New Result `json:"new"`
+}
+
+// Empty reports whether nothing changed
+func (d Diff) Empty() bool {
+	return len(d.Matched) == 0 && len(d.Unmatched) == 0 && len(d.Changed) == 0
+}
+
+// diffKey pairs results of the two runs
+func diffKey(r Result) string {
+	return r.URL + "\x00" + keywordString(r.Keyword)
+}
+
+// DiffResults compares a run against an earlier one, for example read back from a results file, to spot pages where
+// the keyword appeared, went away or shows up a different number of times. Results that errored in the new run and
+// urls only in the old run are left out since nothing is known about them now. Counts come from Result.Count and
+// each list is sorted by url
+func DiffResults(old, new Results) Diff {
+	before := make(map[string]Result, len(old))
+	for _, r := range old {
+		if r.Error == "" {
+			before[diffKey(r)] = r
+		}
+	}
+
+	var d Diff
+	for _, r := range new {
+		if r.Error != "" {
+			continue
+		}
+		prev, seen := before[diffKey(r)]
+		switch {
+		case r.Found && (!seen || !prev.Found):
+			d.Matched = append(d.Matched, r)
+		case !r.Found && seen && prev.Found:
+			d.Unmatched = append(d.Unmatched, r)
+		case r.Found && prev.Count() != r.Count():
+			d.Changed = append(d.Changed, CountChange{Old: prev, New: r})
+		}
+	}
+
+	sort.Sort(d.Matched)
+	sort.Sort(d.Unmatched)
+	sort.Slice(d.Changed, func(i, j int) bool {
+		return d.Changed[i].New.URL < d.Changed[j].New.URL
+	})
+	return d
+}
@@ -0,0 +1,43 @@
+package search
+
+import "testing"
+
+func TestDiffResults(t *testing.T) {
+	two := Matches{{Text: "sign up"}, {Text: "sign up"}}
+	three := Matches{{Text: "sign up"}, {Text: "sign up"}, {Text: "Sign Up"}}
+	old := Results{
+		{URL: "http://a.com", Keyword: "sign up", Found: true},
+		{URL: "http://b.com", Keyword: "sign up"},
+		{URL: "http://c.com", Keyword: "sign up", Found: true, Matches: &two},
+		{URL: "http://d.com", Keyword: "sign up", Found: true},
+		{URL: "http://e.com", Keyword: "sign up", Found: true},
+		{URL: "http://gone.com", Keyword: "sign up", Found: true},
+	}
+	new := Results{
+		{URL: "http://e.com", Keyword: "sign up", Error: "timeout"},
+		{URL: "http://d.com", Keyword: "sign up", Found: true},
+		{URL: "http://c.com", Keyword: "sign up", Found: true, Matches: &three},
+		{URL: "http://b.com", Keyword: "sign up", Found: true},
+		{URL: "http://a.com", Keyword: "sign up"},
+		{URL: "http://a.com", Keyword: "pricing", Found: true},
+		{URL: "http://new.com", Keyword: "sign up", Found: true},
+	}
+
+	d := DiffResults(old, new)
+	if got := d.Matched.MatchingURLs(); len(got) != 3 || got[0] != "http://a.com" || got[1] != "http://b.com" || got[2] != "http://new.com" {
+		t.Errorf("expected a.com for the new keyword, b.com and new.com to have matched, got %v", got)
+	}
+	if len(d.Unmatched) != 1 || d.Unmatched[0].URL != "http://a.com" || d.Unmatched[0].Keyword != "sign up" {
+		t.Errorf("expected a.com to have stopped matching, got %+v", d.Unmatched)
+	}
+	if len(d.Changed) != 1 || d.Changed[0].Old.Count() != 2 || d.Changed[0].New.Count() != 3 {
+		t.Errorf("expected c.com to have gone from 2 to 3 matches, got %+v", d.Changed)
+	}
+	if d.Empty() {
+		t.Error("expected the diff to have changes")
+	}
+
+	if d := DiffResults(old, old); !d.Empty() {
+		t.Errorf("expected no changes between a run and itself, got %+v", d)
+	}
+}
@@ -0,0 +1,130 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultDNSRetryBackoff is the wait before the first dns retry when DNSRetryBackoff isn't set
+const DefaultDNSRetryBackoff = 100 * time.Millisecond
+
+// Resolver looks up the addresses of a host, *net.Resolver satisfies it
+type Resolver interface {
+	LookupHost(ctx context.Context, host string) (addrs []string, err error)
+}
+
+type dnsEntry struct {
+	addrs   []string
+	expires time.Time
+}
+
+// dnsCache resolves each host once per ttl and shares the answer between every dial
+type dnsCache struct {
+	resolver Resolver
+	ttl      time.Duration
+	now      func() time.Time
+
+	mxt     sync.Mutex
+	entries map[string]dnsEntry
+}
+
+func newDNSCache(resolver Resolver, ttl time.Duration) *dnsCache {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	return &dnsCache{resolver: resolver, ttl: ttl, now: time.Now, entries: make(map[string]dnsEntry)}
+}
+
+func (c *dnsCache) lookup(ctx context.Context, host string) ([]string, error) {
+	c.mxt.Lock()
+	e, ok := c.entries[host]
+	c.mxt.Unlock()
+	if ok && c.now().Before(e.expires) {
+		return e.addrs, nil
+	}
+
+	addrs, err := c.resolver.LookupHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mxt.Lock()
+	c.entries[host] = dnsEntry{addrs: addrs, expires: c.now().Add(c.ttl)}
+	c.mxt.Unlock()
+	return addrs, nil
+}
+
+// dialContext wraps dial so host names are resolved through the cache, each address is tried in turn
+func (c *dnsCache) dialContext(dial func(ctx context.Context, network, addr string) (net.Conn, error)) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		if net.ParseIP(host) != nil {
+			return dial(ctx, network, addr)
+		}
+
+		addrs, err := c.lookup(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, ip := range addrs {
+			var conn net.Conn
+			conn, err = dial(ctx, network, net.JoinHostPort(ip, port))
+			if err == nil {
+				return conn, nil
+			}
+		}
+		if err == nil {
+			err = fmt.Errorf("no addresses found for %s", host)
+		}
+		return nil, err
+	}
+}
+
+// EnableDNSCache makes the scanner resolve each host once per ttl instead of on every new connection, which helps
+// large crawls of the same hosts. A nil resolver uses the system one. It only works with the default *http.Transport
+func (sc *Scanner) EnableDNSCache(ttl time.Duration, resolver Resolver) error {
+	t, ok := sc.Client.Transport.(*http.Transport)
+	if !ok {
+		return fmt.Errorf("dns cache needs an *http.Transport, got %T", sc.Client.Transport)
+	}
+
+	dialer := &net.Dialer{Timeout: DefaultTimeout}
+	t.DialContext = newDNSCache(resolver, ttl).dialContext(dialer.DialContext)
+	return nil
+}
+
+// isDNSError reports whether err came from resolving a host in a way that may pass, a host that doesn't exist won't
+func isDNSError(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && !dnsErr.IsNotFound
+}
+
+// dnsBackoff is the wait before the given dns retry, counting from 0
+func (sc *Scanner) dnsBackoff(attempt int) time.Duration {
+	backoff := sc.DNSRetryBackoff
+	if backoff <= 0 {
+		backoff = DefaultDNSRetryBackoff
+	}
+	return backoff << uint(attempt)
+}
+
+// sleep waits for d, false is returned if ctx was done first
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
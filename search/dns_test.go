@@ -0,0 +1,143 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+type countingResolver struct {
+	mxt     sync.Mutex
+	lookups int
+}
+
+func (r *countingResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	r.mxt.Lock()
+	defer r.mxt.Unlock()
+	r.lookups++
+	return []string{"127.0.0.1"}, nil
+}
+
+func TestDNSCache(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<p>sign up</p>")
+	}))
+	defer ts.Close()
+
+	u, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cachedURL := fmt.Sprintf("http://cached.test:%s", u.Port())
+
+	resolver := &countingResolver{}
+	sc := NewScanner(1, 0, false, "")
+	if err := sc.EnableDNSCache(time.Minute, resolver); err != nil {
+		t.Fatal(err)
+	}
+	// force a new dial for every request
+	sc.Client.Transport.(*http.Transport).DisableKeepAlives = true
+
+	for i := 0; i < 3; i++ {
+		res, err := sc.Match(context.Background(), cachedURL, "sign up")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !res.Found {
+			t.Errorf("keyword should have been found")
+		}
+	}
+
+	if resolver.lookups != 1 {
+		t.Errorf("the host should have been resolved once, got %d lookups", resolver.lookups)
+	}
+}
+
+func TestDNSCacheExpires(t *testing.T) {
+	resolver := &countingResolver{}
+	c := newDNSCache(resolver, time.Minute)
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	c.lookup(context.Background(), "example.com")
+	c.lookup(context.Background(), "example.com")
+	if resolver.lookups != 1 {
+		t.Errorf("expected 1 lookup got %d", resolver.lookups)
+	}
+
+	now = now.Add(2 * time.Minute)
+	c.lookup(context.Background(), "example.com")
+	if resolver.lookups != 2 {
+		t.Errorf("the entry should have expired, expected 2 lookups got %d", resolver.lookups)
+	}
+}
+
+// flakyResolver fails the first failures lookups with a temporary dns error
+type flakyResolver struct {
+	mxt      sync.Mutex
+	failures int
+	lookups  int
+}
+
+func (r *flakyResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	r.mxt.Lock()
+	defer r.mxt.Unlock()
+	r.lookups++
+	if r.lookups <= r.failures {
+		return nil, &net.DNSError{Err: "server misbehaving", Name: host, IsTemporary: true}
+	}
+	return []string{"127.0.0.1"}, nil
+}
+
+func TestDNSRetries(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<p>sign up</p>")
+	}))
+	defer ts.Close()
+
+	u, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	flakyURL := fmt.Sprintf("http://flaky.test:%s", u.Port())
+
+	newScanner := func(resolver Resolver, retries int) *Scanner {
+		sc := NewScanner(1, 0, false, "")
+		if err := sc.EnableDNSCache(time.Minute, resolver); err != nil {
+			t.Fatal(err)
+		}
+		sc.DNSRetries = retries
+		sc.DNSRetryBackoff = time.Millisecond
+		return sc
+	}
+
+	resolver := &flakyResolver{failures: 1}
+	res, err := newScanner(resolver, 2).Match(context.Background(), flakyURL, "sign up")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Found || resolver.lookups != 2 {
+		t.Errorf("expected the lookup to be retried once and the keyword found, got %d lookups", resolver.lookups)
+	}
+
+	// without retries the first failure is returned, the https fallback makes the second lookup
+	resolver = &flakyResolver{failures: 2}
+	if _, err := newScanner(resolver, 0).Match(context.Background(), flakyURL, "sign up"); !isDNSError(err) {
+		t.Errorf("expected a dns error without retries, got %v", err)
+	}
+}
+
+func TestDNSNotFoundNotRetried(t *testing.T) {
+	if isDNSError(&net.DNSError{Err: "no such host", Name: "missing.test", IsNotFound: true}) {
+		t.Error("a host that doesn't exist should not be retried")
+	}
+	if isDNSError(fmt.Errorf("connection refused")) {
+		t.Error("only dns errors should be retried")
+	}
+}
@@ -0,0 +1,88 @@
+package search
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// acceptEncoding is the Accept-Encoding sent when Compression is set, every encoding decodeBody can undo
+const acceptEncoding = "gzip, deflate, br"
+
+// decoders open a reader that undoes each content coding, the reader is opened on the first read so an empty body
+// isn't an error
+var decoders = map[string]func(*bufio.Reader) (io.Reader, error){
+	"gzip":   func(r *bufio.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+	"x-gzip": func(r *bufio.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+	"br":     func(r *bufio.Reader) (io.Reader, error) { return brotli.NewReader(r), nil },
+	"deflate": func(r *bufio.Reader) (io.Reader, error) {
+		// deflate is meant to be zlib wrapped but plenty of servers send raw deflate
+		if h, err := r.Peek(2); err == nil && h[0]&0x0f == 8 && (uint(h[0])<<8|uint(h[1]))%31 == 0 {
+			return zlib.NewReader(r)
+		}
+		return flate.NewReader(r), nil
+	},
+}
+
+// decodeBody decompresses a body the transport left compressed, as it does whenever Accept-Encoding was set on the
+// request or a custom transport is used, so keywords are never matched against compressed bytes. Encodings listed
+// together are undone last first, a body with an encoding it doesn't know is left as it is
+func decodeBody(res *http.Response) {
+	if res == nil || res.Uncompressed || res.Request != nil && res.Request.Method == http.MethodHead {
+		return
+	}
+	header := res.Header.Get("Content-Encoding")
+	if header == "" {
+		return
+	}
+	codings := strings.Split(header, ",")
+	for i := range codings {
+		codings[i] = strings.ToLower(strings.TrimSpace(codings[i]))
+		if _, ok := decoders[codings[i]]; !ok && codings[i] != "identity" {
+			return
+		}
+	}
+
+	var r io.Reader = res.Body
+	for i := len(codings) - 1; i >= 0; i-- {
+		if open, ok := decoders[codings[i]]; ok {
+			r = &decodeReader{src: bufio.NewReader(r), open: open}
+		}
+	}
+	res.Body = struct {
+		io.Reader
+		io.Closer
+	}{r, res.Body}
+	res.Header.Del("Content-Encoding")
+	res.Header.Del("Content-Length")
+	res.ContentLength = -1
+	res.Uncompressed = true
+}
+
+// decodeReader opens its decoder on the first read, an empty body reads as empty
+type decodeReader struct {
+	src  *bufio.Reader
+	open func(*bufio.Reader) (io.Reader, error)
+	r    io.Reader
+	err  error
+}
+
+func (d *decodeReader) Read(p []byte) (int, error) {
+	if d.r == nil && d.err == nil {
+		if _, err := d.src.Peek(1); err != nil {
+			d.err = err
+		} else {
+			d.r, d.err = d.open(d.src)
+		}
+	}
+	if d.err != nil {
+		return 0, d.err
+	}
+	return d.r.Read(p)
+}
@@ -0,0 +1,102 @@
+package search
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+func compress(t *testing.T, coding string, body []byte) []byte {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch coding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "zlib":
+		w = zlib.NewWriter(&buf)
+	case "flate":
+		var err error
+		if w, err = flate.NewWriter(&buf, flate.DefaultCompression); err != nil {
+			t.Fatal(err)
+		}
+	case "br":
+		w = brotli.NewWriter(&buf)
+	}
+	if _, err := w.Write(body); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeBody(t *testing.T) {
+	page := []byte(`<html><body><p>sign up today</p></body></html>`)
+	bodies := map[string]struct {
+		encoding string
+		body     []byte
+	}{
+		"/gzip":    {"gzip", compress(t, "gzip", page)},
+		"/zlib":    {"deflate", compress(t, "zlib", page)},
+		"/flate":   {"deflate", compress(t, "flate", page)},
+		"/br":      {"br", compress(t, "br", page)},
+		"/stacked": {"gzip, br", compress(t, "br", compress(t, "gzip", page))},
+	}
+	var accept string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accept = r.Header.Get("Accept-Encoding")
+		b := bodies[r.URL.Path]
+		w.Header().Set("Content-Encoding", b.encoding)
+		w.Write(b.body)
+	}))
+	defer ts.Close()
+
+	sc := NewScanner(1, 0, false, "sign up")
+	sc.Compression = true
+	for path := range bodies {
+		if err := sc.Search(ts.URL + path); err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+	}
+	if accept != acceptEncoding {
+		t.Errorf("expected Accept-Encoding %q, got %q", acceptEncoding, accept)
+	}
+	if len(sc.Results) != len(bodies) {
+		t.Fatalf("expected %d results, got %d", len(bodies), len(sc.Results))
+	}
+	for _, r := range sc.Results {
+		if !r.Found || r.ContentLength != int64(len(page)) {
+			t.Errorf("%s: expected the decompressed page to match, got %+v", r.URL, r)
+		}
+	}
+}
+
+func TestDecodeBodyEmptyAndUnknown(t *testing.T) {
+	for _, tt := range []struct {
+		encoding string
+		body     string
+	}{
+		{"gzip", ""},
+		{"compress", "sign up"},
+	} {
+		res := &http.Response{
+			Header: http.Header{"Content-Encoding": {tt.encoding}},
+			Body:   ioutil.NopCloser(strings.NewReader(tt.body)),
+		}
+		decodeBody(res)
+		got, err := ioutil.ReadAll(res.Body)
+		if err != nil || string(got) != tt.body {
+			t.Errorf("%s: expected %q, got %q %v", tt.encoding, tt.body, got, err)
+		}
+	}
+}
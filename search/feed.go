@@ -0,0 +1,152 @@
+package search
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	log "github.com/marcsantiago/logger"
+)
+
+// feed holds the links of both rss 2.0 and atom documents, whichever the document is the other stays empty
+type feed struct {
+	Items []struct {
+		Link string `xml:"link"`
+	} `xml:"channel>item"`
+	Entries []struct {
+		Links []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"link"`
+	} `xml:"entry"`
+}
+
+// links returns the page link of every item or entry, for atom entries that is the alternate link
+func (f *feed) links() (links []string) {
+	for _, item := range f.Items {
+		if l := strings.TrimSpace(item.Link); l != "" {
+			links = append(links, l)
+		}
+	}
+	for _, entry := range f.Entries {
+		for _, l := range entry.Links {
+			if l.Href != "" && (l.Rel == "" || l.Rel == "alternate") {
+				links = append(links, l.Href)
+				break
+			}
+		}
+	}
+	return
+}
+
+// gunzip decompresses body when it starts with the gzip magic number, anything else is returned as is
+func gunzip(body []byte) ([]byte, error) {
+	if !bytes.HasPrefix(body, []byte{0x1f, 0x8b}) {
+		return body, nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return ioutil.ReadAll(zr)
+}
+
+// parseFeed reads the links out of an rss or atom feed, gzipped feeds are decompressed first
+func parseFeed(body []byte) ([]string, error) {
+	body, err := gunzip(body)
+	if err != nil {
+		return nil, err
+	}
+
+	var f feed
+	if err := xml.Unmarshal(body, &f); err != nil {
+		return nil, err
+	}
+	return f.links(), nil
+}
+
+// CrawlFeed searches for the keyword on every page linked from an rss 2.0 or atom feed, the feed may be gzipped.
+// Each page is searched like Search, following links up to DepthLimit, and the first error is returned. With
+// RespectRobots pages robots.txt disallows are saved as RobotsBlocked instead of searched. Pages are searched by as
+// many workers as the semaphore has slots and the whole feed counts as one url towards Completed
+func (sc *Scanner) CrawlFeed(feedURL, keyword string) error {
+	return sc.CrawlFeedContext(context.Background(), feedURL, keyword)
+}
+
+// CrawlFeedContext is CrawlFeed bound to ctx
+func (sc *Scanner) CrawlFeedContext(ctx context.Context, feedURL, keyword string) error {
+	defer sc.markCompleted(1)
+
+	kw, err := NewKeyword(keyword, KeywordOptions{})
+	if err != nil {
+		return err
+	}
+
+	feedURL, err = sc.normalize(feedURL)
+	if err != nil {
+		return err
+	}
+
+	id, _ := ctx.Value(idKey{}).(string)
+	release, err := sc.acquireContext(ctx, feedURL)
+	if err != nil {
+		sc.fail(Result{URL: feedURL, Keyword: kw.raw, ID: id}, err)
+		return err
+	}
+	body, _, err := sc.fetch(ctx, feedURL)
+	release()
+	if err != nil {
+		sc.fail(Result{URL: feedURL, Keyword: kw.raw, ID: id}, err)
+		return err
+	}
+
+	links, err := parseFeed(body)
+	if err != nil {
+		sc.fail(Result{URL: feedURL, Keyword: kw.raw, ID: id}, err)
+		if sc.Logging {
+			log.Error(logkey, "could not parse feed", "url", feedURL, "error", err)
+		}
+		return err
+	}
+
+	links, blocked := sc.robotsAllowed(ctx, links)
+	for _, link := range blocked {
+		if sc.firstSearch(link, kw.raw) {
+			sc.saveResult(Result{URL: link, Keyword: kw.raw, RobotsBlocked: true, SeedURL: feedURL, ID: id})
+		}
+	}
+
+	// a fixed set of workers keeps the goroutines to the semaphore's size however long the feed
+	workers := cap(sc.Semaphore)
+	if workers < 1 {
+		workers = 1
+	}
+	next := make(chan string)
+	errs := make(chan error, len(links))
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for link := range next {
+				if err := sc.search(ctx, link, kw); err != nil {
+					errs <- err
+				}
+			}
+		}()
+	}
+	for _, link := range links {
+		next <- link
+	}
+	close(next)
+	wg.Wait()
+	close(errs)
+
+	// nil when the channel is empty
+	return <-errs
+}
@@ -0,0 +1,82 @@
+package search
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+)
+
+func TestCrawlFeed(t *testing.T) {
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rss":
+			fmt.Fprintf(w, `<?xml version="1.0"?><rss version="2.0"><channel><title>t</title>
+				<item><title>a</title><link>%[1]s/a</link></item>
+				<item><title>b</title><link> %[1]s/b </link></item>
+			</channel></rss>`, ts.URL)
+		case "/atom":
+			fmt.Fprintf(w, `<?xml version="1.0"?><feed xmlns="http://www.w3.org/2005/Atom"><title>t</title>
+				<entry><link rel="edit" href="%[1]s/edit"/><link href="%[1]s/a"/></entry>
+				<entry><link rel="alternate" href="%[1]s/c"/></entry>
+			</feed>`, ts.URL)
+		case "/rss.gz":
+			var buf bytes.Buffer
+			zw := gzip.NewWriter(&buf)
+			fmt.Fprintf(zw, `<rss version="2.0"><channel><item><link>%s/c</link></item></channel></rss>`, ts.URL)
+			zw.Close()
+			w.Write(buf.Bytes())
+		case "/a", "/c":
+			fmt.Fprint(w, "<html><body><p>sign up</p></body></html>")
+		default:
+			fmt.Fprint(w, "<html><body><p>nothing here</p></body></html>")
+		}
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		feed  string
+		found []string
+		all   int
+	}{
+		{"/rss", []string{ts.URL + "/a"}, 2},
+		{"/atom", []string{ts.URL + "/a", ts.URL + "/c"}, 2},
+		{"/rss.gz", []string{ts.URL + "/c"}, 1},
+	}
+	for _, test := range tests {
+		sc := NewScanner(2, 0, false, "")
+		if err := sc.CrawlFeed(ts.URL+test.feed, "sign up"); err != nil {
+			t.Fatalf("%s: %v", test.feed, err)
+		}
+		if len(sc.Results) != test.all {
+			t.Errorf("%s: expected %d results, got %d", test.feed, test.all, len(sc.Results))
+		}
+		found := sc.Results.MatchingURLs()
+		sort.Strings(found)
+		if fmt.Sprint(found) != fmt.Sprint(test.found) {
+			t.Errorf("%s: expected %v to match, got %v", test.feed, test.found, found)
+		}
+		if sc.Completed() != 1 {
+			t.Errorf("%s: expected the feed to count as one url, got %d", test.feed, sc.Completed())
+		}
+	}
+}
+
+func TestCrawlFeedInvalid(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<html><body><p>not a feed")
+	}))
+	defer ts.Close()
+
+	sc := NewScanner(1, 0, false, "")
+	if err := sc.CrawlFeed(ts.URL, "sign up"); err == nil {
+		t.Error("expected an error for a page that isn't a feed")
+	}
+	if sc.Completed() != 1 {
+		t.Errorf("expected a feed that failed to still count as one url, got %d", sc.Completed())
+	}
+}
@@ -0,0 +1,65 @@
+package search
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// Fetcher gets the body, status code and headers of a url. Setting one on the scanner replaces its http requests,
+// which lets tests feed canned pages or replay recorded ones without a server
+type Fetcher interface {
+	Fetch(ctx context.Context, URL string) ([]byte, int, http.Header, error)
+}
+
+// HTTPFetcher is a Fetcher doing a plain GET with Client, http.DefaultClient when nil. It is handy to wrap, e.g. to
+// record responses, the scanner's own requests also handle DNS retries, InsecureHosts and the https fallback
+type HTTPFetcher struct {
+	Client *http.Client
+}
+
+// Fetch implements Fetcher
+func (f HTTPFetcher) Fetch(ctx context.Context, URL string) ([]byte, int, http.Header, error) {
+	req, err := http.NewRequest(http.MethodGet, URL, nil)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	client := f.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	return body, res.StatusCode, res.Header, err
+}
+
+// fetchWith is get through the Fetcher, a failed http url is retried over https like open does. The response only
+// carries the status code and headers, its body is empty
+func (sc *Scanner) fetchWith(ctx context.Context, URL string) (*http.Response, []byte, string, error) {
+	body, status, header, err := sc.Fetcher.Fetch(ctx, URL)
+	if err != nil && !strings.Contains(URL, "https:") {
+		URL = strings.Replace(URL, "http", "https", 1)
+		body, status, header, err = sc.Fetcher.Fetch(ctx, URL)
+	}
+	if err != nil {
+		return nil, nil, URL, err
+	}
+
+	if header == nil {
+		header = make(http.Header)
+	}
+	res := &http.Response{StatusCode: status, Status: http.StatusText(status), Header: header, Body: http.NoBody}
+	if sc.MaxBodyBytes > 0 && int64(len(body)) > sc.MaxBodyBytes {
+		body = body[:sc.MaxBodyBytes]
+		res.Body = &cappedBody{ReadCloser: http.NoBody, checked: true, truncated: true}
+	}
+	res.ContentLength = int64(len(body))
+	return res, body, URL, nil
+}
@@ -0,0 +1,69 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"testing"
+)
+
+// cannedFetcher serves fixed pages by url and records what was asked for
+type cannedFetcher struct {
+	mxt     sync.Mutex
+	pages   map[string]string
+	fetched []string
+}
+
+func (f *cannedFetcher) Fetch(ctx context.Context, URL string) ([]byte, int, http.Header, error) {
+	f.mxt.Lock()
+	defer f.mxt.Unlock()
+	f.fetched = append(f.fetched, URL)
+	body, ok := f.pages[URL]
+	if !ok {
+		return nil, 0, nil, errors.New("no canned page for " + URL)
+	}
+	return []byte(body), http.StatusOK, http.Header{"Server": {"canned"}}, nil
+}
+
+func TestFetcher(t *testing.T) {
+	f := &cannedFetcher{pages: map[string]string{
+		"http://example.com":         `<html><body><a href="http://example.com/about">about</a></body></html>`,
+		"http://example.com/about":   `<html><body><p>sign up</p></body></html>`,
+		"https://secure.example.com": `<html><body><p>sign up</p></body></html>`,
+	}}
+
+	sc := NewScanner(1, 2, false, "sign up")
+	sc.Fetcher = f
+	if err := sc.Search("example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Sort(sc.Results)
+	if len(sc.Results) != 2 || sc.Results[0].Found || !sc.Results[1].Found {
+		t.Fatalf("expected the keyword on the about page only, got %+v", sc.Results)
+	}
+
+	// http fails with the canned fetcher so the https fallback has to kick in
+	r, err := sc.Match(context.Background(), "secure.example.com", "sign up")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Found || r.URL != "https://secure.example.com" {
+		t.Errorf("expected the https page to be matched, got %+v", r)
+	}
+
+	r, err = sc.MatchWithHeaders(context.Background(), "http://example.com/about", "sign up",
+		[]HeaderMatcher{{Name: "Server"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Found {
+		t.Error("expected the canned headers to be matched")
+	}
+
+	if _, err := sc.Match(context.Background(), "https://example.com/missing", "sign up"); err == nil {
+		t.Error("expected the fetcher's error")
+	}
+}
@@ -0,0 +1,39 @@
+package search
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Filter returns the results pred keeps, in order. Filters compose by chaining e.g
+// results.Filter(FoundOnly).Filter(DomainEquals("example.com"))
+func (slice Results) Filter(pred func(Result) bool) Results {
+	var out Results
+	for _, r := range slice {
+		if pred(r) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// FoundOnly keeps results where the keyword was found
+func FoundOnly(r Result) bool {
+	return r.Found
+}
+
+// ErroredOnly keeps results for urls that failed, they are only saved when SaveErrors is set
+func ErroredOnly(r Result) bool {
+	return r.Error != ""
+}
+
+// DomainEquals keeps results on host, ignoring case. The port is only compared when host has one
+func DomainEquals(host string) func(Result) bool {
+	return func(r Result) bool {
+		u, err := url.Parse(r.URL)
+		if err != nil {
+			return false
+		}
+		return strings.EqualFold(u.Host, host) || strings.EqualFold(u.Hostname(), host)
+	}
+}
@@ -0,0 +1,66 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+var filterResults = Results{
+	{URL: "http://a.com", Found: true},
+	{URL: "http://a.com/about"},
+	{URL: "http://B.com:8080/x", Found: true},
+	{URL: "http://c.com", Error: "timed out"},
+}
+
+func urlsOf(results Results) []string {
+	var urls []string
+	for _, r := range results {
+		urls = append(urls, r.URL)
+	}
+	return urls
+}
+
+func TestFilter(t *testing.T) {
+	tests := []struct {
+		name string
+		got  Results
+		want []string
+	}{
+		{"FoundOnly", filterResults.Filter(FoundOnly), []string{"http://a.com", "http://B.com:8080/x"}},
+		{"ErroredOnly", filterResults.Filter(ErroredOnly), []string{"http://c.com"}},
+		{"DomainEquals", filterResults.Filter(DomainEquals("a.com")), []string{"http://a.com", "http://a.com/about"}},
+		{"DomainEquals port", filterResults.Filter(DomainEquals("b.com:8080")), []string{"http://B.com:8080/x"}},
+		{"DomainEquals wrong port", filterResults.Filter(DomainEquals("b.com:9090")), nil},
+		{"chained", filterResults.Filter(FoundOnly).Filter(DomainEquals("a.com")), []string{"http://a.com"}},
+	}
+	for _, test := range tests {
+		if fmt.Sprint(urlsOf(test.got)) != fmt.Sprint(test.want) {
+			t.Errorf("%s: expected %v got %v", test.name, test.want, urlsOf(test.got))
+		}
+	}
+}
+
+func TestSaveErrors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<p>sign up</p>")
+	}))
+	defer ts.Close()
+
+	sc := NewScanner(2, 0, false, "sign up")
+	sc.SaveErrors = true
+	err := sc.SearchBatch(context.Background(), []string{ts.URL, "http://unresolvable.invalid"})
+	if err == nil {
+		t.Fatal("expected the unresolvable url to fail")
+	}
+
+	errored := sc.Results.Filter(ErroredOnly)
+	if len(errored) != 1 || errored[0].URL != "http://unresolvable.invalid" {
+		t.Errorf("expected the failed url to be saved with its error, got %+v", errored)
+	}
+	if len(sc.Results.Filter(FoundOnly)) != 1 {
+		t.Errorf("expected the working url to still be found, got %+v", sc.Results)
+	}
+}
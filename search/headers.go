@@ -0,0 +1,173 @@
+package search
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"time"
+
+	log "github.com/marcsantiago/logger"
+)
+
+// HeaderMatcher requires a response header whose value matches Pattern, a nil Pattern only requires the header to be
+// present
+type HeaderMatcher struct {
+	// Name is the header name, it is matched case insensitively
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// match reports whether any value of the header matches
+func (m HeaderMatcher) match(h http.Header) bool {
+	values := h.Values(m.Name)
+	if m.Pattern == nil {
+		return len(values) > 0
+	}
+	for _, v := range values {
+		if m.Pattern.MatchString(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchWithHeaders is Match for fingerprints that need the response headers and the body, e.g. a Server: nginx
+// header along with a string in the page. Found is only set when the keyword is in the body and every header
+// matcher is satisfied, both are checked against the same response
+func (sc *Scanner) MatchWithHeaders(ctx context.Context, URL, keyword string, headers []HeaderMatcher) (Result, error) {
+	URL, err := sc.normalize(URL)
+	if err != nil {
+		if sc.Logging {
+			log.Error(logkey, "could not normalize url", "error", err)
+		}
+		return Result{}, err
+	}
+
+	kw, err := NewKeyword(keyword, KeywordOptions{})
+	if err != nil {
+		return Result{}, err
+	}
+
+	release, err := sc.acquireContext(ctx, URL)
+	if err != nil {
+		return Result{}, err
+	}
+	defer release()
+
+	start := time.Now()
+	res, body, URL, err := sc.fetchResponse(ctx, URL)
+	if err != nil {
+		return Result{}, err
+	}
+	elapsed := time.Since(start)
+
+	r := sc.evaluate(URL, kw, body)
+	r.Duration = elapsed
+	sc.describeResponse(&r, res)
+	for _, m := range headers {
+		if !m.match(res.Header) {
+			r.Found, r.Context, r.Matches, r.Score = false, nil, nil, 0
+			break
+		}
+	}
+	return r, nil
+}
+
+// headerKey carries the headers passed to WithHeader down to the requests
+type headerKey struct{}
+
+// WithHeader returns a copy of ctx whose headers are set on every request the Search methods send with it, replacing
+// the scanner's Header and UserAgent. Headers from an outer WithHeader are kept unless h replaces them
+func WithHeader(ctx context.Context, h http.Header) context.Context {
+	merged := make(http.Header)
+	if outer, ok := ctx.Value(headerKey{}).(http.Header); ok {
+		for k, v := range outer {
+			merged[k] = v
+		}
+	}
+	for k, v := range h {
+		merged[http.CanonicalHeaderKey(k)] = v
+	}
+	return context.WithValue(ctx, headerKey{}, merged)
+}
+
+// withHeaders returns the request with the scanner's default headers, HostHeader included, and credentials, which
+// don't replace headers already on it, and the ctx headers, which do. The request passed in isn't changed
+func (sc *Scanner) withHeaders(req *http.Request) *http.Request {
+	override, _ := req.Context().Value(headerKey{}).(http.Header)
+	creds := sc.credentials(req)
+	defaults := sc.Header
+	if host, ok := sc.HostHeader[req.URL.Host]; ok {
+		defaults = mergeHeaders(sc.Header, host)
+	} else if host, ok := sc.HostHeader[req.URL.Hostname()]; ok {
+		defaults = mergeHeaders(sc.Header, host)
+	}
+	if len(defaults) == 0 && sc.UserAgent == "" && !sc.Compression && len(override) == 0 && creds.empty() {
+		return req
+	}
+
+	req = req.Clone(req.Context())
+	if !creds.empty() && req.Header.Get("Authorization") == "" {
+		creds.set(req)
+	}
+	for k, v := range defaults {
+		if req.Header.Get(k) == "" {
+			req.Header[http.CanonicalHeaderKey(k)] = v
+		}
+	}
+	if sc.UserAgent != "" && req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", sc.UserAgent)
+	}
+	if sc.Compression && req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	for k, v := range override {
+		req.Header[k] = v
+	}
+	return req
+}
+
+// mergeHeaders returns a copy of h with the values of over added, replacing those of h for the same names
+func mergeHeaders(h, over http.Header) http.Header {
+	merged := make(http.Header, len(h)+len(over))
+	for k, v := range h {
+		merged[http.CanonicalHeaderKey(k)] = v
+	}
+	for k, v := range over {
+		merged[http.CanonicalHeaderKey(k)] = v
+	}
+	return merged
+}
+
+// Credentials authenticate requests with a bearer token or, when Token is empty, basic auth
+type Credentials struct {
+	Username string
+	Password string
+	Token    string
+}
+
+// empty reports whether there is nothing to authenticate with
+func (c Credentials) empty() bool {
+	return c == Credentials{}
+}
+
+// set adds the Authorization header for the credentials
+func (c Credentials) set(req *http.Request) {
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+		return
+	}
+	req.SetBasicAuth(c.Username, c.Password)
+}
+
+// credentials are the credentials for the request's host, from HostAuth by host and port or by host alone, falling
+// back to Auth
+func (sc *Scanner) credentials(req *http.Request) Credentials {
+	if c, ok := sc.HostAuth[req.URL.Host]; ok {
+		return c
+	}
+	if c, ok := sc.HostAuth[req.URL.Hostname()]; ok {
+		return c
+	}
+	return sc.Auth
+}
@@ -0,0 +1,152 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestMatchWithHeaders(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", "nginx/1.25.3")
+		w.Header().Set("X-Powered-By", "PHP/8.2")
+		fmt.Fprint(w, "<html><body><p>Welcome to nginx!</p></body></html>")
+	}))
+	defer ts.Close()
+
+	nginx := HeaderMatcher{Name: "server", Pattern: regexp.MustCompile(`(?i)^nginx`)}
+	for _, test := range []struct {
+		name    string
+		keyword string
+		headers []HeaderMatcher
+		found   bool
+	}{
+		{"header and body", "welcome to nginx", []HeaderMatcher{nginx}, true},
+		{"present header", "welcome to nginx", []HeaderMatcher{nginx, {Name: "X-Powered-By"}}, true},
+		{"body missing", "apache", []HeaderMatcher{nginx}, false},
+		{"header value wrong", "welcome to nginx", []HeaderMatcher{{Name: "Server", Pattern: regexp.MustCompile("apache")}}, false},
+		{"header missing", "welcome to nginx", []HeaderMatcher{nginx, {Name: "X-Cache"}}, false},
+	} {
+		sc := NewScanner(1, 0, false, "")
+		r, err := sc.MatchWithHeaders(context.Background(), ts.URL, test.keyword, test.headers)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Found != test.found {
+			t.Errorf("%s: expected found %v got %v", test.name, test.found, r.Found)
+		}
+		if !r.Found && r.Context != nil && r.Context != "" {
+			t.Errorf("%s: expected no context without a match, got %v", test.name, r.Context)
+		}
+	}
+}
+
+func TestRequestHeaders(t *testing.T) {
+	var (
+		mxt  sync.Mutex
+		seen = make(map[string]http.Header)
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mxt.Lock()
+		seen[r.URL.Path] = r.Header.Clone()
+		mxt.Unlock()
+		fmt.Fprint(w, `<html><body><p>sign up</p></body></html>`)
+	}))
+	defer ts.Close()
+
+	sc := NewScanner(1, 0, false, "sign up")
+	sc.UserAgent = "searchbot/1.0"
+	sc.Header = http.Header{"accept-language": {"fr"}, "X-Team": {"growth"}}
+	if err := sc.Search(ts.URL + "/default"); err != nil {
+		t.Fatal(err)
+	}
+	ctx := WithHeader(context.Background(), http.Header{"User-Agent": {"other/2.0"}})
+	ctx = WithHeader(ctx, http.Header{"x-team": {"ads"}})
+	if err := sc.SearchContext(ctx, ts.URL+"/override"); err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest(http.MethodGet, ts.URL+"/prebuilt", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Accept-Language", "de")
+	if err := sc.SearchWithRequest(req, "sign up"); err != nil {
+		t.Fatal(err)
+	}
+
+	mxt.Lock()
+	defer mxt.Unlock()
+	for path, want := range map[string][3]string{
+		"/default":  {"searchbot/1.0", "fr", "growth"},
+		"/override": {"other/2.0", "fr", "ads"},
+		"/prebuilt": {"searchbot/1.0", "de", "growth"},
+	} {
+		h := seen[path]
+		if got := [3]string{h.Get("User-Agent"), h.Get("Accept-Language"), h.Get("X-Team")}; got != want {
+			t.Errorf("%s: expected user agent, language and team %v, got %v", path, want, got)
+		}
+	}
+	if req.Header.Get("User-Agent") != "" {
+		t.Errorf("the prebuilt request shouldn't be changed")
+	}
+}
+
+func TestAuth(t *testing.T) {
+	basic := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "docs" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, "<html><body><p>internal docs</p></body></html>")
+	}))
+	defer basic.Close()
+	bearer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer t0ken" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, "<html><body><p>internal docs</p></body></html>")
+	}))
+	defer bearer.Close()
+
+	sc := NewScanner(1, 0, false, "internal docs")
+	sc.Auth = Credentials{Username: "docs", Password: "secret"}
+	sc.HostAuth = map[string]Credentials{strings.TrimPrefix(bearer.URL, "http://"): {Token: "t0ken"}}
+	for _, u := range []string{basic.URL, bearer.URL} {
+		if err := sc.Search(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, r := range sc.Results {
+		if !r.Found || r.StatusCode != http.StatusOK {
+			t.Errorf("expected %s to be searched with its credentials, got %+v", r.URL, r)
+		}
+	}
+}
+
+func TestHostHeader(t *testing.T) {
+	sc := NewScanner(1, 0, false, "")
+	sc.Header = http.Header{"Accept-Language": {"en"}, "X-Team": {"growth"}}
+	sc.HostHeader = map[string]http.Header{"a.com": {"accept-language": {"fr"}}}
+	for raw, want := range map[string][2]string{
+		"http://a.com/": {"fr", "growth"},
+		"http://b.com/": {"en", "growth"},
+	} {
+		req, err := http.NewRequest(http.MethodGet, raw, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h := sc.withHeaders(req).Header
+		if got := [2]string{h.Get("Accept-Language"), h.Get("X-Team")}; got != want {
+			t.Errorf("%s: expected language and team %v, got %v", raw, want, got)
+		}
+	}
+	if len(sc.Header["Accept-Language"]) != 1 || sc.Header.Get("Accept-Language") != "en" {
+		t.Error("Header shouldn't be changed by HostHeader")
+	}
+}
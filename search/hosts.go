@@ -0,0 +1,148 @@
+package search
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// hostLimiter hands out a semaphore per host, each is sized when the host is first seen
+type hostLimiter struct {
+	mxt  sync.Mutex
+	sems map[string]Semaphore
+}
+
+func (h *hostLimiter) semaphore(host string, limit int) Semaphore {
+	h.mxt.Lock()
+	defer h.mxt.Unlock()
+
+	if h.sems == nil {
+		h.sems = make(map[string]Semaphore)
+	}
+	s, ok := h.sems[host]
+	if !ok {
+		s = make(Semaphore, limit)
+		h.sems[host] = s
+	}
+	return s
+}
+
+// acquire takes a slot for the URL's host, when MaxConcurrentPerHost is set, and then a global slot. The returned
+// func gives both back
+func (sc *Scanner) acquire(URL string) (release func()) {
+	release, _ = sc.acquireContext(context.Background(), URL)
+	return release
+}
+
+// acquireContext is acquire giving up once ctx is done, nothing is held when it returns an error
+func (sc *Scanner) acquireContext(ctx context.Context, URL string) (release func(), err error) {
+	if sc.MaxConcurrentPerHost <= 0 {
+		if err := sc.Semaphore.loadContext(ctx); err != nil {
+			return nil, err
+		}
+		return sc.Semaphore.release, nil
+	}
+
+	var host string
+	if u, err := url.Parse(URL); err == nil {
+		host = u.Host
+	}
+	hs := sc.hosts.semaphore(host, sc.MaxConcurrentPerHost)
+	if err := hs.loadContext(ctx); err != nil {
+		return nil, err
+	}
+	if err := sc.Semaphore.loadContext(ctx); err != nil {
+		hs.release()
+		return nil, err
+	}
+	return func() {
+		sc.Semaphore.release()
+		hs.release()
+	}, nil
+}
+
+// tryAcquire is acquire without waiting, ok is false and nothing is held when either slot is taken
+func (sc *Scanner) tryAcquire(URL string) (release func(), ok bool) {
+	if sc.MaxConcurrentPerHost <= 0 {
+		if !sc.Semaphore.tryLoad() {
+			return nil, false
+		}
+		return sc.Semaphore.release, true
+	}
+
+	var host string
+	if u, err := url.Parse(URL); err == nil {
+		host = u.Host
+	}
+	hs := sc.hosts.semaphore(host, sc.MaxConcurrentPerHost)
+	if !hs.tryLoad() {
+		return nil, false
+	}
+	if !sc.Semaphore.tryLoad() {
+		hs.release()
+		return nil, false
+	}
+	return func() {
+		sc.Semaphore.release()
+		hs.release()
+	}, true
+}
+
+// hostPacer spaces out requests to each host, every request books the next free slot of its host and waits for it
+type hostPacer struct {
+	mxt  sync.Mutex
+	next map[string]time.Time
+}
+
+// wait blocks until the host's next slot, at least interval after the one before it, false is returned if ctx was
+// done first
+func (h *hostPacer) wait(ctx context.Context, host string, interval time.Duration) bool {
+	h.mxt.Lock()
+	if h.next == nil {
+		h.next = make(map[string]time.Time)
+	}
+	now := time.Now()
+	at := h.next[host]
+	if at.Before(now) {
+		at = now
+	}
+	h.next[host] = at.Add(interval)
+	h.mxt.Unlock()
+
+	if d := at.Sub(now); d > 0 {
+		return sleep(ctx, d)
+	}
+	return true
+}
+
+// hostRate is the rate HostRates sets for the host, by host and port or by host alone, falling back to HostRate
+func (sc *Scanner) hostRate(u *url.URL) float64 {
+	if rate, ok := sc.HostRates[u.Host]; ok {
+		return rate
+	}
+	if rate, ok := sc.HostRates[u.Hostname()]; ok {
+		return rate
+	}
+	return sc.HostRate
+}
+
+// pace waits for the request's host to be free under its HostRates or HostRate or, when longer, its robots.txt
+// Crawl-delay
+func (sc *Scanner) pace(req *http.Request) error {
+	var interval time.Duration
+	if rate := sc.hostRate(req.URL); rate > 0 {
+		interval = time.Duration(float64(time.Second) / rate)
+	}
+	if d := sc.crawlDelay(req.URL.Host); d > interval {
+		interval = d
+	}
+	if interval <= 0 {
+		return nil
+	}
+	if !sc.pacer.wait(req.Context(), req.URL.Host, interval) {
+		return req.Context().Err()
+	}
+	return nil
+}
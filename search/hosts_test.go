@@ -0,0 +1,220 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+// gauge tracks how many requests are in flight and the most seen at once
+type gauge struct {
+	mxt      sync.Mutex
+	cur, max int
+	last     time.Time
+}
+
+func (g *gauge) server(delay time.Duration, total *gauge) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.inc()
+		total.inc()
+		time.Sleep(delay)
+		total.dec()
+		g.dec()
+		fmt.Fprint(w, "<p>sign up</p>")
+	}))
+}
+
+func (g *gauge) inc() {
+	g.mxt.Lock()
+	defer g.mxt.Unlock()
+	g.cur++
+	if g.cur > g.max {
+		g.max = g.cur
+	}
+}
+
+func (g *gauge) dec() {
+	g.mxt.Lock()
+	defer g.mxt.Unlock()
+	g.cur--
+	g.last = time.Now()
+}
+
+func TestMaxConcurrentPerHost(t *testing.T) {
+	var total, slow, fast gauge
+	slowTS := slow.server(30*time.Millisecond, &total)
+	defer slowTS.Close()
+
+	var urls []string
+	for i := 0; i < 6; i++ {
+		urls = append(urls, fmt.Sprintf("%s/%d", slowTS.URL, i))
+	}
+	for i := 0; i < 3; i++ {
+		ts := fast.server(0, &total)
+		defer ts.Close()
+		urls = append(urls, ts.URL)
+	}
+
+	sc := NewScanner(3, 0, false, "sign up")
+	sc.MaxConcurrentPerHost = 1
+	if err := sc.SearchBatch(context.Background(), urls); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sc.Results) != len(urls) {
+		t.Errorf("expected %d results got %d", len(urls), len(sc.Results))
+	}
+	if slow.max != 1 {
+		t.Errorf("expected at most one request to the slow host at once, got %d", slow.max)
+	}
+	if total.max > 3 {
+		t.Errorf("expected the global limit of 3 to hold, got %d", total.max)
+	}
+	if !fast.last.Before(slow.last) {
+		t.Error("expected the fast hosts to finish while the slow host was still queued")
+	}
+}
+
+func TestHostRate(t *testing.T) {
+	var (
+		mxt   sync.Mutex
+		times []time.Time
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mxt.Lock()
+		times = append(times, time.Now())
+		mxt.Unlock()
+		fmt.Fprint(w, `<html><body><p>sign up</p></body></html>`)
+	}))
+	defer ts.Close()
+
+	sc := NewScanner(4, 0, false, "sign up")
+	sc.HostRate = 20
+	urls := []string{ts.URL + "/a", ts.URL + "/b", ts.URL + "/c", ts.URL + "/d"}
+	if err := sc.SearchBatch(context.Background(), urls); err != nil {
+		t.Fatal(err)
+	}
+
+	mxt.Lock()
+	defer mxt.Unlock()
+	if len(times) != len(urls) {
+		t.Fatalf("expected %d requests, got %d", len(urls), len(times))
+	}
+	for i := 1; i < len(times); i++ {
+		// a little slack for the timer firing early relative to when the handler runs
+		if gap := times[i].Sub(times[i-1]); gap < 40*time.Millisecond {
+			t.Errorf("expected requests 50ms apart, request %d came %v after the one before", i, gap)
+		}
+	}
+}
+
+func TestHostRatePerPort(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><p>sign up</p></body></html>`)
+	})
+	a, b := httptest.NewServer(handler), httptest.NewServer(handler)
+	defer a.Close()
+	defer b.Close()
+
+	// both servers are on 127.0.0.1, only the ports differ, so each paces on its own
+	sc := NewScanner(1, 0, false, "sign up")
+	sc.HostRate = 1
+	start := time.Now()
+	for _, URL := range []string{a.URL, b.URL} {
+		if err := sc.Search(URL); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected hosts on different ports not to wait on each other, took %v", elapsed)
+	}
+}
+
+func TestShare(t *testing.T) {
+	var (
+		mxt   sync.Mutex
+		times []time.Time
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mxt.Lock()
+		times = append(times, time.Now())
+		mxt.Unlock()
+		fmt.Fprint(w, `<html><body><p>sign up</p></body></html>`)
+	}))
+	defer ts.Close()
+
+	sc := NewScanner(4, 0, false, "sign up")
+	shared := sc.Share("sign up")
+	if shared.Client != sc.Client || cap(shared.Semaphore) != 4 {
+		t.Fatal("expected the shared scanner to use the same client and semaphore")
+	}
+
+	var wg sync.WaitGroup
+	for i, s := range []*Scanner{sc, shared} {
+		s.HostRate = 20
+		wg.Add(1)
+		go func(s *Scanner, URL string) {
+			defer wg.Done()
+			if err := s.Search(URL); err != nil {
+				t.Error(err)
+			}
+		}(s, fmt.Sprintf("%s/%d", ts.URL, i))
+	}
+	wg.Wait()
+
+	if len(sc.Results) != 1 || len(shared.Results) != 1 {
+		t.Errorf("expected each scanner to keep its own result, got %d and %d", len(sc.Results), len(shared.Results))
+	}
+	mxt.Lock()
+	defer mxt.Unlock()
+	if len(times) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(times))
+	}
+	if gap := times[1].Sub(times[0]); gap < 40*time.Millisecond {
+		t.Errorf("expected the host's pacing to hold across both scanners, the requests came %v apart", gap)
+	}
+}
+
+func TestHostPacer(t *testing.T) {
+	var p hostPacer
+	ctx := context.Background()
+	start := time.Now()
+	p.wait(ctx, "a.com", time.Hour)
+	p.wait(ctx, "b.com", time.Hour)
+	if time.Since(start) > 100*time.Millisecond {
+		t.Errorf("the first request to each host shouldn't wait")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	if p.wait(ctx, "a.com", time.Hour) {
+		t.Errorf("expected the wait for a.com's next slot to give up once ctx is done")
+	}
+}
+
+func TestHostRates(t *testing.T) {
+	sc := NewScanner(1, 0, false, "")
+	sc.HostRate = 2
+	sc.HostRates = map[string]float64{"a.com": 0.5, "b.com:8080": 10, "c.com": 0}
+	for raw, want := range map[string]float64{
+		"http://a.com/x":      0.5,
+		"http://a.com:8080/x": 0.5,
+		"http://b.com:8080/x": 10,
+		"http://b.com/x":      2,
+		"http://c.com/x":      0,
+		"http://d.com/x":      2,
+	} {
+		u, err := url.Parse(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := sc.hostRate(u); got != want {
+			t.Errorf("%s: expected rate %v, got %v", raw, want, got)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"sync"
+)
+
+// KeywordOptions changes how a keyword is compiled
+type KeywordOptions struct {
+	// CaseSensitive turns off the default case insensitive matching
+	CaseSensitive bool
+	// Literal escapes the keyword so regular expression characters match themselves
+	Literal bool
+}
+
+// Keyword is a keyword compiled once so it can be reused across many searches
+type Keyword struct {
+	raw          string
+	opts         KeywordOptions
+	searchRegex  *regexp.Regexp
+	contextRegex *regexp.Regexp
+
+	// forms caches the keyword compiled in each unicode normalization form
+	mxt   sync.Mutex
+	forms map[UnicodeForm]*Keyword
+}
+
+// NewKeyword compiles the keyword into the regexes used for searching and pulling out context, compile errors are
+// returned here rather than on every search
+func NewKeyword(keyword string, opts KeywordOptions) (*Keyword, error) {
+	pattern := keyword
+	if opts.Literal {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+
+	searchRegex, err := CompilePattern(keyword, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	flags := "(?i)"
+	if opts.CaseSensitive {
+		flags = ""
+	} else if strings.Contains(pattern, "(?i)") {
+		pattern = strings.Replace(pattern, "(?i)", "", 1)
+	}
+
+	contextRegex, err := regexp.Compile(fmt.Sprintf("%s(<[^<]+)(%s)([^>]+>)", flags, pattern))
+	if err != nil {
+		return nil, err
+	}
+	return &Keyword{raw: keyword, opts: opts, searchRegex: searchRegex, contextRegex: contextRegex}, nil
+}
+
+// PatternError explains why a pattern does not compile
+type PatternError struct {
+	// Pattern is the pattern as it was passed in
+	Pattern string
+	// Offset is where in Pattern the problem starts, -1 when it can't be pinned down
+	Offset int
+	// Message describes the problem, e.g. "missing closing )"
+	Message string
+	Err     error
+}
+
+func (e *PatternError) Error() string {
+	if e.Offset < 0 {
+		return fmt.Sprintf("invalid pattern %q: %s", e.Pattern, e.Message)
+	}
+	return fmt.Sprintf("invalid pattern %q: %s at offset %d", e.Pattern, e.Message, e.Offset)
+}
+
+func (e *PatternError) Unwrap() error {
+	return e.Err
+}
+
+// CompilePattern compiles the pattern the same way NewKeyword does, case insensitive unless asked otherwise, and
+// returns a *PatternError saying what is wrong and where instead of panicking like regexp.MustCompile
+func CompilePattern(pattern string, opts KeywordOptions) (*regexp.Regexp, error) {
+	expr := pattern
+	if opts.Literal {
+		expr = regexp.QuoteMeta(expr)
+	}
+	// a pattern already carrying (?i) is used as is
+	if !opts.CaseSensitive && !strings.Contains(expr, "(?i)") {
+		expr = "(?i)" + expr
+	}
+
+	re, err := regexp.Compile(expr)
+	if err == nil {
+		return re, nil
+	}
+
+	perr := &PatternError{Pattern: pattern, Offset: -1, Message: err.Error(), Err: err}
+	if serr, ok := err.(*syntax.Error); ok {
+		perr.Message = string(serr.Code)
+		if serr.Expr != "" {
+			perr.Offset = strings.Index(pattern, serr.Expr)
+		}
+		if perr.Offset >= 0 && serr.Expr != pattern {
+			perr.Message += fmt.Sprintf(" in %q", serr.Expr)
+		}
+	}
+	return nil, perr
+}
+
+// mustKeyword compiles the keyword with the default options and panics on a bad pattern
+func mustKeyword(keyword string) *Keyword {
+	kw, err := NewKeyword(keyword, KeywordOptions{})
+	if err != nil {
+		panic(err)
+	}
+	return kw
+}
+
+// String returns the keyword as it was passed in
+func (kw *Keyword) String() string {
+	return kw.raw
+}
+
+// SearchCompiled is Search with a keyword compiled ahead of time, use it when scanning many urls for the same term
+func (sc *Scanner) SearchCompiled(URL string, kw *Keyword) error {
+	return sc.SearchCompiledContext(context.Background(), URL, kw)
+}
+
+// SearchCompiledContext is SearchCompiled bound to ctx
+func (sc *Scanner) SearchCompiledContext(ctx context.Context, URL string, kw *Keyword) error {
+	defer sc.markCompleted(1)
+	return sc.search(ctx, URL, kw)
+}
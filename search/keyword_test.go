@@ -0,0 +1,115 @@
+package search
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCompilePattern(t *testing.T) {
+	_, err := CompilePattern("sign up|free *trial*[", KeywordOptions{})
+	perr, ok := err.(*PatternError)
+	if !ok {
+		t.Fatalf("expected a *PatternError got %v", err)
+	}
+	if perr.Offset != 20 {
+		t.Errorf("expected the problem at offset 20 got %d", perr.Offset)
+	}
+	if !strings.Contains(perr.Error(), "missing closing ]") {
+		t.Errorf("expected the syntax problem in the message got %q", perr.Error())
+	}
+
+	_, err = CompilePattern("a**", KeywordOptions{})
+	if perr, ok := err.(*PatternError); !ok || perr.Offset != 1 {
+		t.Errorf("expected the problem at offset 1 got %v", err)
+	}
+
+	re, err := CompilePattern("Sign Up", KeywordOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !re.MatchString("please sign up") {
+		t.Errorf("patterns should be case insensitive by default")
+	}
+}
+
+func TestNewKeyword(t *testing.T) {
+	if _, err := NewKeyword("sign (up", KeywordOptions{}); err == nil {
+		t.Errorf("a bad pattern should error instead of panicking")
+	}
+
+	kw, err := NewKeyword("Sign Up", KeywordOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !kw.searchRegex.MatchString("please sign up") {
+		t.Errorf("keywords should be case insensitive by default")
+	}
+
+	kw, err = NewKeyword("Sign Up", KeywordOptions{CaseSensitive: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kw.searchRegex.MatchString("please sign up") {
+		t.Errorf("a case sensitive keyword should not match a different case")
+	}
+
+	kw, err = NewKeyword("1+1 (two)", KeywordOptions{Literal: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !kw.searchRegex.MatchString("so 1+1 (two) it is") {
+		t.Errorf("a literal keyword should match itself")
+	}
+
+	if kw.String() != "1+1 (two)" {
+		t.Errorf("String should return the keyword as passed, got %s", kw.String())
+	}
+}
+
+func TestSearchCompiled(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<p>sign up</p>")
+	}))
+	defer ts.Close()
+
+	kw, err := NewKeyword("sign up", KeywordOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sc := NewScanner(2, 0, false, "")
+	for _, u := range []string{ts.URL + "/a", ts.URL + "/b"} {
+		if err := sc.SearchCompiled(u, kw); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(sc.Results) != 2 || !sc.Results[0].Found || sc.Results[1].Keyword != "sign up" {
+		t.Errorf("unexpected results %+v", sc.Results)
+	}
+}
+
+var benchBody = []byte("<html><body>" + strings.Repeat("<p>lorem ipsum dolor sit amet</p>", 200) + "<p>sign up today</p></body></html>")
+
+func BenchmarkKeywordRecompiled(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		kw, err := NewKeyword(`sign\s+up\s+(today|now)`, KeywordOptions{})
+		if err != nil {
+			b.Fatal(err)
+		}
+		matchBody(benchBody, kw)
+	}
+}
+
+func BenchmarkKeywordCompiled(b *testing.B) {
+	kw, err := NewKeyword(`sign\s+up\s+(today|now)`, KeywordOptions{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	for i := 0; i < b.N; i++ {
+		matchBody(benchBody, kw)
+	}
+}
@@ -0,0 +1,54 @@
+package search
+
+import (
+	"math/rand"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// LinkOrdering is the order links found on a page are looked at in
+type LinkOrdering int
+
+const (
+	// LinkDocument keeps links in the order they appear in the page
+	LinkDocument LinkOrdering = iota
+	// LinkLexical sorts links as strings
+	LinkLexical
+	// LinkDepth puts links with fewer path segments first, the closest to the root of the site, ties are sorted as
+	// strings
+	LinkDepth
+	// LinkShuffled shuffles links with LinkSeed
+	LinkShuffled
+)
+
+// pathDepth is the number of segments in the link's path, unparsable links go last
+func pathDepth(link string) int {
+	u, err := url.Parse(link)
+	if err != nil {
+		return int(^uint(0) >> 1)
+	}
+	return len(strings.FieldsFunc(u.Path, func(r rune) bool { return r == '/' }))
+}
+
+// orderLinks sorts links in place according to LinkOrdering
+func (sc *Scanner) orderLinks(links []string) {
+	switch sc.LinkOrdering {
+	case LinkLexical:
+		sort.Strings(links)
+	case LinkDepth:
+		sort.SliceStable(links, func(i, j int) bool {
+			if di, dj := pathDepth(links[i]), pathDepth(links[j]); di != dj {
+				return di < dj
+			}
+			return links[i] < links[j]
+		})
+	case LinkShuffled:
+		// shuffling the sorted links keeps the pick stable when a page lists the same links in a different order
+		sort.Strings(links)
+		r := rand.New(rand.NewSource(sc.LinkSeed))
+		r.Shuffle(len(links), func(i, j int) {
+			links[i], links[j] = links[j], links[i]
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package search
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLinkOrdering(t *testing.T) {
+	base := "http://example.com"
+	page := func(paths ...string) []byte {
+		var b strings.Builder
+		for _, p := range paths {
+			fmt.Fprintf(&b, `<a href="%s%s">%s</a>`, base, p, p)
+		}
+		return []byte("<html><body>" + b.String() + "</body></html>")
+	}
+	// the same links in two different orders, as a page might serve them on different visits
+	first := page("/c", "/a/b/c", "/b", "/a/b", "/a")
+	second := page("/a", "/a/b", "/b", "/a/b/c", "/c")
+
+	sc := NewScanner(1, 0, false, "")
+	sc.MaxLinksPerPage = 3
+	if reflect.DeepEqual(sc.extractLinks(first, base, 0), sc.extractLinks(second, base, 0)) {
+		t.Error("expected the page order to decide the links kept by default")
+	}
+
+	for _, test := range []struct {
+		ordering LinkOrdering
+		want     []string
+	}{
+		{LinkLexical, []string{base, base + "/a", base + "/a/b", base + "/a/b/c"}},
+		{LinkDepth, []string{base, base + "/a", base + "/b", base + "/c"}},
+	} {
+		sc.LinkOrdering = test.ordering
+		for _, body := range [][]byte{first, second} {
+			if got := sc.extractLinks(body, base, 0); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("ordering %d: expected %v got %v", test.ordering, test.want, got)
+			}
+		}
+	}
+
+	sc.LinkOrdering = LinkShuffled
+	sc.LinkSeed = 42
+	want := sc.extractLinks(first, base, 0)
+	for i := 0; i < 5; i++ {
+		if got := sc.extractLinks(second, base, 0); !reflect.DeepEqual(got, want) {
+			t.Fatalf("expected the same seed to pick %v every run, got %v", want, got)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package search
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/marcsantiago/logger"
+)
+
+// SearchMany fetches the URL once and saves a Result for every keyword, in the order the keywords are given. The
+// keywords are matched against the page concurrently, up to KeywordConcurrency at once. No links are followed
+func (sc *Scanner) SearchMany(URL string, keywords []string) (err error) {
+	return sc.SearchManyContext(context.Background(), URL, keywords)
+}
+
+// SearchManyContext is SearchMany bound to ctx
+func (sc *Scanner) SearchManyContext(ctx context.Context, URL string, keywords []string) (err error) {
+	defer sc.markCompleted(1)
+
+	kws := make([]*Keyword, len(keywords))
+	for i, keyword := range keywords {
+		if kws[i], err = NewKeyword(keyword, KeywordOptions{}); err != nil {
+			return err
+		}
+	}
+
+	id, _ := ctx.Value(idKey{}).(string)
+	defer func() {
+		if err != nil {
+			for _, kw := range kws {
+				sc.fail(Result{URL: URL, Keyword: kw.raw, ID: id}, err)
+			}
+		}
+	}()
+
+	URL, err = sc.normalize(URL)
+	if err != nil {
+		if sc.Logging {
+			log.Error(logkey, "could not normalize url", "error", err)
+		}
+		return err
+	}
+	raws := make([]string, len(kws))
+	for i, kw := range kws {
+		raws[i] = kw.raw
+	}
+	keys := strings.Join(raws, "\n")
+	if !sc.firstSearch(URL, keys) {
+		return nil
+	}
+	defer sc.forgetOnError(&err, URL, keys)
+
+	release, err := sc.acquireContext(ctx, URL)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	start := time.Now()
+	res, body, URL, err := sc.fetchResponse(ctx, URL)
+	if err != nil {
+		return err
+	}
+	elapsed := time.Since(start)
+
+	buf := sc.newResultBuffer()
+	defer buf.flush()
+	for _, r := range sc.evaluateMany(URL, kws, body) {
+		r.Duration = elapsed
+		sc.describeResponse(&r, res)
+		r.SeedURL = URL
+		r.ID = id
+		buf.save(r)
+	}
+	return nil
+}
+
+// evaluateMany is evaluate for every keyword, run on up to KeywordConcurrency goroutines. The keywords' regexes
+// are only read so they can be shared
+func (sc *Scanner) evaluateMany(URL string, kws []*Keyword, body []byte) Results {
+	workers := sc.KeywordConcurrency
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(kws) {
+		workers = len(kws)
+	}
+
+	results := make(Results, len(kws))
+	next := make(chan int, len(kws))
+	for i := range kws {
+		next <- i
+	}
+	close(next)
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i] = sc.evaluate(URL, kws[i], body)
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
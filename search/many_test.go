@@ -0,0 +1,70 @@
+package search
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSearchMany(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		fmt.Fprint(w, "<html><body><p>sign up</p><p>free trial</p></body></html>")
+	}))
+	defer ts.Close()
+
+	sc := NewScanner(1, 0, false, "")
+	sc.KeywordConcurrency = 2
+	keywords := []string{"sign up", "pricing", "free trial", "contact us", `trial\b`}
+	if err := sc.SearchMany(ts.URL, keywords); err != nil {
+		t.Fatal(err)
+	}
+
+	if hits := atomic.LoadInt32(&hits); hits != 1 {
+		t.Errorf("expected the page to be fetched once, it was fetched %d times", hits)
+	}
+	if len(sc.Results) != len(keywords) {
+		t.Fatalf("expected a result per keyword got %d", len(sc.Results))
+	}
+	for i, want := range []bool{true, false, true, false, true} {
+		if r := sc.Results[i]; r.Keyword != keywords[i] || r.Found != want {
+			t.Errorf("expected %q found %v got %q found %v", keywords[i], want, r.Keyword, r.Found)
+		}
+	}
+
+	if err := sc.SearchMany(ts.URL, []string{"sign up", "bad ("}); err == nil {
+		t.Error("expected a bad keyword to error before fetching")
+	}
+	if hits := atomic.LoadInt32(&hits); hits != 1 {
+		t.Errorf("expected a bad keyword to stop the fetch, the page was fetched %d times", hits)
+	}
+}
+
+func BenchmarkEvaluateMany(b *testing.B) {
+	var page strings.Builder
+	page.WriteString("<html><body>")
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(&page, "<p class=\"item\">product %d is on sale, order now</p>", i)
+	}
+	page.WriteString("</body></html>")
+	body := []byte(page.String())
+
+	kws := make([]*Keyword, 50)
+	for i := range kws {
+		kws[i] = mustKeyword(fmt.Sprintf(`product %d\d* is (on sale|sold out)`, i*3))
+	}
+
+	for _, workers := range []int{1, 4, 0} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			sc := NewScanner(1, 0, false, "")
+			sc.KeywordConcurrency = workers
+			for i := 0; i < b.N; i++ {
+				sc.evaluateMany("http://example.com", kws, body)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package search
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// DefaultConcurrency is how many requests a scanner built by New runs at once when WithConcurrency isn't passed
+const DefaultConcurrency = 20
+
+// Option configures a scanner built by New
+type Option func(*options) error
+
+type options struct {
+	concurrency int
+	depth       int
+	logging     bool
+	keyword     string
+	kwOpts      KeywordOptions
+	timeout     time.Duration
+	client      *http.Client
+	bufferPool  int
+}
+
+// WithConcurrency caps how many requests run at once, it must be at least 1
+func WithConcurrency(n int) Option {
+	return func(o *options) error {
+		if n < 1 {
+			return fmt.Errorf("concurrency must be at least 1, got %d", n)
+		}
+		o.concurrency = n
+		return nil
+	}
+}
+
+// WithDepth sets how many levels of links are followed from each url searched
+func WithDepth(depth int) Option {
+	return func(o *options) error {
+		if depth < 0 {
+			return fmt.Errorf("depth can't be negative, got %d", depth)
+		}
+		o.depth = depth
+		return nil
+	}
+}
+
+// WithLogging turns logging on or off
+func WithLogging(enabled bool) Option {
+	return func(o *options) error {
+		o.logging = enabled
+		return nil
+	}
+}
+
+// WithKeyword sets the keyword searched for, it is compiled with opts so an invalid pattern fails New
+func WithKeyword(keyword string, opts KeywordOptions) Option {
+	return func(o *options) error {
+		o.keyword, o.kwOpts = keyword, opts
+		return nil
+	}
+}
+
+// WithTimeout sets how long a single request may take, it applies to the client passed to WithClient too
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) error {
+		if timeout <= 0 {
+			return fmt.Errorf("timeout must be positive, got %s", timeout)
+		}
+		o.timeout = timeout
+		return nil
+	}
+}
+
+// WithClient sends the requests with client in place of the default one, redirects are then left to the client's
+// CheckRedirect unless it has none
+func WithClient(client *http.Client) Option {
+	return func(o *options) error {
+		if client == nil {
+			return fmt.Errorf("client is nil")
+		}
+		o.client = client
+		return nil
+	}
+}
+
+// WithBufferPoolSize sets how many read buffers are kept to be reused, see Scanner.BufferPoolSize
+func WithBufferPoolSize(n int) Option {
+	return func(o *options) error {
+		o.bufferPool = n
+		return nil
+	}
+}
+
+// New returns a scanner configured by opts, the options can be given in any order. Without any it is the same as
+// NewScanner(DefaultConcurrency, 0, false, "")
+func New(opts ...Option) (*Scanner, error) {
+	o := options{concurrency: DefaultConcurrency}
+	for _, opt := range opts {
+		if err := opt(&o); err != nil {
+			return nil, err
+		}
+	}
+
+	kw, err := NewKeyword(o.keyword, o.kwOpts)
+	if err != nil {
+		return nil, err
+	}
+	sc := NewScanner(o.concurrency, o.depth, o.logging, "")
+	sc.Keyword, sc.kw = o.keyword, kw
+	if o.client != nil {
+		sc.Client = o.client
+		if sc.Client.CheckRedirect == nil {
+			sc.Client.CheckRedirect = sc.checkRedirect
+		}
+	}
+	if o.timeout > 0 {
+		sc.Client.Timeout = o.timeout
+	}
+	sc.BufferPoolSize = o.bufferPool
+	return sc, nil
+}
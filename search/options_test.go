@@ -0,0 +1,54 @@
+package search
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><p>a.b</p></body></html>`)
+	}))
+	defer ts.Close()
+
+	client := &http.Client{}
+	sc, err := New(
+		WithTimeout(5*time.Second),
+		WithClient(client),
+		WithConcurrency(3),
+		WithDepth(2),
+		WithKeyword("a.b", KeywordOptions{Literal: true}),
+		WithBufferPoolSize(-1),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cap(sc.Semaphore) != 3 || sc.DepthLimit != 2 || sc.Client != client || client.Timeout != 5*time.Second ||
+		client.CheckRedirect == nil || sc.Logging || sc.BufferPoolSize != -1 {
+		t.Errorf("options weren't applied, got %+v", sc)
+	}
+	if err := sc.Search(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	if len(sc.Results) != 1 || !sc.Results[0].Found {
+		t.Errorf("expected the literal keyword to be found, got %+v", sc.Results)
+	}
+
+	sc, err = New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cap(sc.Semaphore) != DefaultConcurrency || sc.Client.Timeout != DefaultTimeout {
+		t.Errorf("expected the defaults, got %+v", sc)
+	}
+
+	for _, opt := range []Option{WithConcurrency(0), WithDepth(-1), WithTimeout(0), WithClient(nil),
+		WithKeyword("(", KeywordOptions{})} {
+		if _, err := New(opt); err == nil {
+			t.Error("expected an error for an invalid option")
+		}
+	}
+}
@@ -0,0 +1,283 @@
+package search
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Format is an output format for results
+type Format int
+
+const (
+	// FormatCSV writes url,found,context,duration rows with a header
+	FormatCSV Format = iota
+	// FormatJSON writes a single json array
+	FormatJSON
+	// FormatJSONL writes one json object per line
+	FormatJSONL
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatCSV:
+		return "csv"
+	case FormatJSON:
+		return "json"
+	case FormatJSONL:
+		return "jsonl"
+	}
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+// GetResults returns a copy of the results taken under the lock, unlike reading Results directly it is safe to call
+// while searches are still saving results. Results saved afterwards don't show up in the copy
+func (sc *Scanner) GetResults() Results {
+	sc.mxt.Lock()
+	defer sc.mxt.Unlock()
+	r := make(Results, len(sc.Results))
+	copy(r, sc.Results)
+	return r
+}
+
+// dedup keeps the first result for every url and keyword pair
+func (slice Results) dedup() Results {
+	seen := make(map[string]bool)
+	var out Results
+	for _, r := range slice {
+		k := r.URL + "\x00" + keywordString(r.Keyword)
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		out = append(out, r)
+	}
+	return out
+}
+
+// WriteResultsFile writes the results saved so far to path in the given format, replacing the file if it exists.
+// DedupResults and SortResults are applied first
+func (sc *Scanner) WriteResultsFile(path string, format Format) error {
+	return writeResultsFile(path, sc.outputResults(), format, sc.contextSeparator())
+}
+
+// WriteResultsByDomain writes the results saved so far into dir with one file per domain, named after the host with
+// anything but letters, digits, dots and dashes replaced by _ e.g example.com_8080.csv. The directory is created if
+// needed and files in it are replaced. DedupResults and SortResults are applied first
+func (sc *Scanner) WriteResultsByDomain(dir string, format Format) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for host, results := range sc.outputResults().GroupByDomain() {
+		path := filepath.Join(dir, domainFileName(host)+"."+format.String())
+		if err := writeResultsFile(path, results, format, sc.contextSeparator()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9.-]`)
+
+// domainFileName makes a host safe to use as a file name
+func domainFileName(host string) string {
+	name := unsafeFileChars.ReplaceAllString(host, "_")
+	// keep names like "..", which only hold safe characters, from climbing out of the directory
+	name = strings.Trim(name, ".")
+	if name == "" {
+		return "unknown"
+	}
+	return name
+}
+
+// outputResults is a snapshot of the results with DedupResults and SortResults applied
+func (sc *Scanner) outputResults() Results {
+	results := sc.GetResults()
+	if sc.DedupResults {
+		results = results.dedup()
+	}
+	if sc.SortResults {
+		sort.Sort(results)
+	}
+	return results
+}
+
+// contextSeparator is ContextSeparator or DefaultContextSeparator when it isn't set
+func (sc *Scanner) contextSeparator() string {
+	if sc.ContextSeparator == "" {
+		return DefaultContextSeparator
+	}
+	return sc.ContextSeparator
+}
+
+func writeResultsFile(path string, results Results, format Format, sep string) (err error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}()
+
+	w := bufio.NewWriter(f)
+	if err := writeResults(w, results, format, sep); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// writeResults writes results in the format, sep joins list contexts in csv while json keeps them as arrays
+func writeResults(w io.Writer, results Results, format Format, sep string) error {
+	var sink Sink
+	switch format {
+	case FormatJSON:
+		if results == nil {
+			results = Results{}
+		}
+		return json.NewEncoder(w).Encode(results)
+	case FormatJSONL:
+		sink = NewJSONLSink(w)
+	case FormatCSV:
+		csv := NewCSVSink(w)
+		csv.Separator = sep
+		sink = csv
+	default:
+		return fmt.Errorf("unknown format %v", format)
+	}
+
+	for _, r := range results {
+		if err := sink.Write(r); err != nil {
+			return err
+		}
+	}
+	return sink.Close()
+}
+
+// WriteJSONL writes every result as a json object on its own line, ready for jq or a log pipeline. Unlike
+// ResultsToReader the results are encoded one at a time rather than into a single array held in memory
+func (slice Results) WriteJSONL(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	for _, r := range slice {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// tsvEscaper keeps a field on one line and free of tabs, backslashes are escaped too so the output can be unescaped
+var tsvEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+// Count is how many times the keyword was found, the number of Matches when they were collected and otherwise 1 for
+// a found result
+func (r Result) Count() int {
+	switch {
+	case r.Matches != nil:
+		return len(*r.Matches)
+	case r.Found:
+		return 1
+	}
+	return 0
+}
+
+// WriteTSV writes a url, found, count and context header followed by one tab separated row per result. Tabs,
+// newlines and backslashes in the context are written as \t, \n and \\ so every result stays on one line
+func (slice Results) WriteTSV(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	if _, err := io.WriteString(bw, "url\tfound\tcount\tcontext\n"); err != nil {
+		return err
+	}
+	for _, r := range slice {
+		_, err := fmt.Fprintf(bw, "%s\t%t\t%d\t%s\n", tsvEscaper.Replace(r.URL), r.Found, r.Count(),
+			tsvEscaper.Replace(contextString(r.Context)))
+		if err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// DefaultCSVColumns are the columns WriteCSV writes when none are chosen, the same as CSVSink
+var DefaultCSVColumns = []string{"url", "found", "context", "duration"}
+
+// csvColumns format each column WriteCSV can write
+var csvColumns = map[string]func(r Result, sep string) string{
+	"url":            func(r Result, _ string) string { return r.URL },
+	"keyword":        func(r Result, _ string) string { return keywordString(r.Keyword) },
+	"found":          func(r Result, _ string) string { return strconv.FormatBool(r.Found) },
+	"context":        func(r Result, sep string) string { return ContextString(r.Context, sep) },
+	"count":          func(r Result, _ string) string { return strconv.Itoa(r.Count()) },
+	"score":          func(r Result, _ string) string { return strconv.FormatFloat(r.Score, 'f', -1, 64) },
+	"match_source":   func(r Result, _ string) string { return r.MatchSource },
+	"status_code":    func(r Result, _ string) string { return strconv.Itoa(r.StatusCode) },
+	"content_length": func(r Result, _ string) string { return strconv.FormatInt(r.ContentLength, 10) },
+	"truncated":      func(r Result, _ string) string { return strconv.FormatBool(r.Truncated) },
+	"soft_not_found": func(r Result, _ string) string { return strconv.FormatBool(r.SoftNotFound) },
+	"thin_content":   func(r Result, _ string) string { return strconv.FormatBool(r.ThinContent) },
+	"robots_blocked": func(r Result, _ string) string { return strconv.FormatBool(r.RobotsBlocked) },
+	"duration":       func(r Result, _ string) string { return r.Duration.String() },
+	"id":             func(r Result, _ string) string { return r.ID },
+	"seed_url":       func(r Result, _ string) string { return r.SeedURL },
+	"error":          func(r Result, _ string) string { return r.Error },
+}
+
+// CSVOptions choose what WriteCSV writes
+type CSVOptions struct {
+	// Columns are the columns in the order they are written, named after the json fields of Result e.g. status_code.
+	// Defaults to DefaultCSVColumns
+	Columns []string
+	// NoHeader leaves out the header row of column names
+	NoHeader bool
+	// Separator joins the values of a list context, defaults to DefaultContextSeparator
+	Separator string
+}
+
+// WriteCSV writes one row per result, quoting fields that hold commas, quotes or newlines. An unknown column is an
+// error and nothing is written
+func (slice Results) WriteCSV(w io.Writer, opts CSVOptions) error {
+	columns := opts.Columns
+	if len(columns) == 0 {
+		columns = DefaultCSVColumns
+	}
+	formats := make([]func(Result, string) string, len(columns))
+	for i, c := range columns {
+		f, ok := csvColumns[c]
+		if !ok {
+			return fmt.Errorf("unknown csv column %q", c)
+		}
+		formats[i] = f
+	}
+	sep := opts.Separator
+	if sep == "" {
+		sep = DefaultContextSeparator
+	}
+
+	cw := csv.NewWriter(w)
+	if !opts.NoHeader {
+		if err := cw.Write(columns); err != nil {
+			return err
+		}
+	}
+	row := make([]string, len(columns))
+	for _, r := range slice {
+		for i, f := range formats {
+			row[i] = f(r, sep)
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,186 @@
+package search
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteResultsFile(t *testing.T) {
+	sc := NewScanner(1, 0, false, "sign up")
+	sc.SortResults = true
+	sc.DedupResults = true
+	sc.Results = Results{
+		Result{URL: "c.com", Keyword: "sign up"},
+		Result{URL: "a.com", Keyword: "sign up", Found: true, Context: "<p>sign up</p>"},
+		Result{URL: "c.com", Keyword: "sign up"},
+	}
+
+	dir := t.TempDir()
+
+	csvPath := filepath.Join(dir, "out.csv")
+	if err := sc.WriteResultsFile(csvPath, FormatCSV); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(csvPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "url,found,context,duration\na.com,true,<p>sign up</p>,0s\nc.com,false,,0s\n"
+	if string(b) != want {
+		t.Errorf("expected %q got %q", want, string(b))
+	}
+
+	jsonPath := filepath.Join(dir, "out.json")
+	if err := sc.WriteResultsFile(jsonPath, FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	b, err = ioutil.ReadFile(jsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var results Results
+	if err := json.Unmarshal(b, &results); err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].URL != "a.com" {
+		t.Errorf("unexpected results %+v", results)
+	}
+
+	jsonlPath := filepath.Join(dir, "out.jsonl")
+	if err := sc.WriteResultsFile(jsonlPath, FormatJSONL); err != nil {
+		t.Fatal(err)
+	}
+	b, err = ioutil.ReadFile(jsonlPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(b)), "\n"); len(lines) != 2 {
+		t.Errorf("expected 2 lines got %d", len(lines))
+	}
+
+	if err := sc.WriteResultsFile(filepath.Join(dir, "out.bad"), Format(42)); err == nil {
+		t.Errorf("an unknown format should error")
+	}
+}
+
+func TestWriteResultsByDomain(t *testing.T) {
+	sc := NewScanner(1, 0, false, "")
+	sc.Results = Results{
+		{URL: "http://a.com", Found: true},
+		{URL: "http://A.com/about"},
+		{URL: "http://b.com:8080/x", Found: true},
+	}
+
+	dir := filepath.Join(t.TempDir(), "by-domain")
+	if err := sc.WriteResultsByDomain(dir, FormatJSONL); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name())
+	}
+	if want := "[a.com.jsonl b.com_8080.jsonl]"; fmt.Sprint(names) != want {
+		t.Fatalf("expected files %s got %v", want, names)
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "a.com.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(b), "\n"); n != 2 {
+		t.Errorf("expected both a.com results in its file, got %d lines", n)
+	}
+}
+
+func TestDomainFileName(t *testing.T) {
+	for host, want := range map[string]string{
+		"example.com":      "example.com",
+		"example.com:8080": "example.com_8080",
+		"[::1]:80":         "___1__80",
+		"..":               "unknown",
+		"":                 "unknown",
+	} {
+		if got := domainFileName(host); got != want {
+			t.Errorf("%q: expected %q got %q", host, want, got)
+		}
+	}
+}
+
+func TestWriteTSV(t *testing.T) {
+	matches := Matches{{Text: "sign up"}, {Text: "Sign Up"}}
+	results := Results{
+		{URL: "http://a.com", Found: true, Context: "<p>sign up\tnow\nor \\ later</p>", Matches: &matches},
+		{URL: "http://b.com", Found: true, Context: "<p>sign up</p>"},
+		{URL: "http://c.com"},
+	}
+
+	var buf bytes.Buffer
+	if err := results.WriteTSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "url\tfound\tcount\tcontext\n" +
+		"http://a.com\ttrue\t2\t<p>sign up\\tnow\\nor \\\\ later</p>\n" +
+		"http://b.com\ttrue\t1\t<p>sign up</p>\n" +
+		"http://c.com\tfalse\t0\t\n"
+	if buf.String() != expected {
+		t.Errorf("expected\n%q\ngot\n%q", expected, buf.String())
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	results := Results{
+		{URL: "http://a.com", Found: true, Context: `<a title="sign up, now">sign up</a>`, StatusCode: 200},
+		{URL: "http://b.com", Found: true, Context: []string{"a@b.com", "c@d.com"}, StatusCode: 200},
+		{URL: "http://c.com", StatusCode: 404},
+	}
+
+	var buf bytes.Buffer
+	if err := results.WriteCSV(&buf, CSVOptions{Columns: []string{"url", "status_code", "context"}}); err != nil {
+		t.Fatal(err)
+	}
+	expected := "url,status_code,context\n" +
+		"http://a.com,200,\"<a title=\"\"sign up, now\"\">sign up</a>\"\n" +
+		"http://b.com,200,a@b.com; c@d.com\n" +
+		"http://c.com,404,\n"
+	if buf.String() != expected {
+		t.Errorf("expected\n%q\ngot\n%q", expected, buf.String())
+	}
+
+	buf.Reset()
+	if err := results[2:].WriteCSV(&buf, CSVOptions{NoHeader: true}); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "http://c.com,false,,0s\n" {
+		t.Errorf("expected the default columns without a header, got %q", buf.String())
+	}
+
+	buf.Reset()
+	if err := results.WriteCSV(&buf, CSVOptions{Columns: []string{"url", "nope"}}); err == nil || buf.Len() != 0 {
+		t.Errorf("expected an error and no output for an unknown column, got %v %q", err, buf.String())
+	}
+}
+
+func TestWriteJSONL(t *testing.T) {
+	results := Results{{URL: "http://a.com", Found: true, Context: "<p>sign up</p>"}, {URL: "http://b.com"}}
+
+	var buf bytes.Buffer
+	if err := results.WriteJSONL(&buf); err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"url":"http://a.com","found":true,"context":"\u003cp\u003esign up\u003c/p\u003e"}` + "\n" +
+		`{"url":"http://b.com"}` + "\n"
+	if buf.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, buf.String())
+	}
+}
@@ -0,0 +1,111 @@
+package search
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	log "github.com/marcsantiago/logger"
+)
+
+// DefaultMaxPages bounds SearchPaginated when no page limit is passed
+const DefaultMaxPages = 100
+
+// NextPageFunc returns the url of the page after the one whose body is passed, an empty url ends the pagination.
+// Relative urls are resolved against the current page
+type NextPageFunc func(body []byte) (string, error)
+
+// SearchPaginated searches a paginated resource such as a json api that hands out a cursor in each response. Every
+// page is searched for the keyword then next is asked for the following page until it returns an empty url, a page
+// repeats or maxPages pages were searched, maxPages <= 0 uses DefaultMaxPages. Urls are used as is so query strings
+// holding the cursor are kept. With RespectRobots a page after the first that robots.txt disallows is saved as
+// RobotsBlocked and ends the run
+func (sc *Scanner) SearchPaginated(ctx context.Context, startURL, keyword string, next NextPageFunc, maxPages int) error {
+	defer sc.markCompleted(1)
+
+	if startURL == "" {
+		return ErrURLEmpty
+	}
+	if maxPages <= 0 {
+		maxPages = DefaultMaxPages
+	}
+
+	kw, err := NewKeyword(keyword, KeywordOptions{})
+	if err != nil {
+		return err
+	}
+
+	id, _ := ctx.Value(idKey{}).(string)
+	sc.robotsFirst(ctx, startURL)
+	seen := make(map[string]bool)
+	URL := startURL
+	for page := 0; page < maxPages && URL != "" && !seen[URL]; page++ {
+		seen[URL] = true
+		// with DedupURLs a page searched before ends the run like a repeated one, its cursor isn't fetched again
+		if !sc.firstSearch(URL, kw.raw) {
+			return nil
+		}
+		if page > 0 {
+			if _, blocked := sc.robotsAllowed(ctx, []string{URL}); len(blocked) > 0 {
+				sc.saveResult(Result{URL: URL, Keyword: kw.raw, RobotsBlocked: true, SeedURL: startURL, ID: id})
+				return nil
+			}
+		}
+		if sc.Logging {
+			log.Info(logkey, "looking for keyword", "keyword", keyword, "url", URL, "page", page)
+		}
+
+		r, body, fetched, err := sc.searchPaginatedPage(ctx, URL, kw)
+		if err != nil {
+			sc.fail(Result{URL: URL, Keyword: kw.raw, SeedURL: startURL, ID: id}, err)
+			sc.forgetSearches([]string{URL}, kw.raw)
+			return err
+		}
+		r.SeedURL = startURL
+		r.ID = id
+		sc.saveResult(r)
+
+		nextURL, err := next(body)
+		if err != nil || nextURL == "" {
+			return err
+		}
+		URL, err = resolveURL(fetched, nextURL)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// searchPaginatedPage searches one page bounded by the scanner's semaphores. Its body and the url it was fetched from,
+// after redirects, are returned for the next cursor
+func (sc *Scanner) searchPaginatedPage(ctx context.Context, URL string, kw *Keyword) (Result, []byte, string, error) {
+	release, err := sc.acquireContext(ctx, URL)
+	if err != nil {
+		return Result{}, nil, URL, err
+	}
+	defer release()
+
+	start := time.Now()
+	res, body, fetched, err := sc.fetchResponse(ctx, URL)
+	if err != nil {
+		return Result{}, nil, fetched, err
+	}
+	r := sc.evaluate(fetched, kw, body)
+	r.Duration = time.Since(start)
+	sc.describeResponse(&r, res)
+	return r, body, fetched, nil
+}
+
+// resolveURL resolves ref against base
+func resolveURL(base, ref string) (string, error) {
+	b, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	r, err := url.Parse(ref)
+	if err != nil {
+		return "", err
+	}
+	return b.ResolveReference(r).String(), nil
+}
@@ -0,0 +1,85 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func cursorAPI() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("cursor") {
+		case "":
+			fmt.Fprint(w, `{"items": ["nothing here"], "next": "/items?cursor=abc"}`)
+		case "abc":
+			fmt.Fprint(w, `{"items": ["sign up today"], "next": ""}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func nextFromJSON(body []byte) (string, error) {
+	var v struct {
+		Next string `json:"next"`
+	}
+	err := json.Unmarshal(body, &v)
+	return v.Next, err
+}
+
+func TestSearchPaginated(t *testing.T) {
+	ts := cursorAPI()
+	defer ts.Close()
+
+	sc := NewScanner(1, 0, false, "")
+	if err := sc.SearchPaginated(context.Background(), ts.URL+"/items", "sign up", nextFromJSON, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sc.Results) != 2 {
+		t.Fatalf("expected both pages to be searched, got %+v", sc.Results)
+	}
+	if sc.Results[0].Found || sc.Results[0].URL != ts.URL+"/items" {
+		t.Errorf("expected the first page to not match, got %+v", sc.Results[0])
+	}
+	if !sc.Results[1].Found || sc.Results[1].URL != ts.URL+"/items?cursor=abc" {
+		t.Errorf("expected the second page to match, got %+v", sc.Results[1])
+	}
+
+	sc = NewScanner(1, 0, false, "")
+	if err := sc.SearchPaginated(WithID(context.Background(), "7"), ts.URL+"/items", "sign up", nextFromJSON, 0); err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range sc.Results {
+		if r.StatusCode != http.StatusOK || r.ContentLength == 0 || r.Duration <= 0 || r.SeedURL != ts.URL+"/items" ||
+			r.ID != "7" {
+			t.Errorf("expected the response details, seed and id on every page, got %+v", r)
+		}
+	}
+}
+
+func TestSearchPaginatedMaxPages(t *testing.T) {
+	ts := cursorAPI()
+	defer ts.Close()
+
+	sc := NewScanner(1, 0, false, "")
+	if err := sc.SearchPaginated(context.Background(), ts.URL+"/items", "sign up", nextFromJSON, 1); err != nil {
+		t.Fatal(err)
+	}
+	if len(sc.Results) != 1 {
+		t.Errorf("expected max pages to stop after one page, got %d", len(sc.Results))
+	}
+
+	// a cursor pointing back at the same page stops rather than looping until max pages
+	sc = NewScanner(1, 0, false, "")
+	same := func([]byte) (string, error) { return ts.URL + "/items", nil }
+	if err := sc.SearchPaginated(context.Background(), ts.URL+"/items", "sign up", same, 10); err != nil {
+		t.Fatal(err)
+	}
+	if len(sc.Results) != 1 {
+		t.Errorf("expected a repeated page to end pagination, got %d results", len(sc.Results))
+	}
+}
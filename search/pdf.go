@@ -0,0 +1,84 @@
+package search
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// PDFExtractor turns a pdf into its text, plug in a full pdf library for documents BasicPDFExtractor can't read
+type PDFExtractor interface {
+	ExtractText(pdf []byte) (string, error)
+}
+
+// BasicPDFExtractor reads the text shown by simple pdfs using only the standard library. It understands plain and
+// FlateDecode content streams with literal strings, text drawn with custom font encodings comes out garbled
+type BasicPDFExtractor struct{}
+
+var (
+	pdfStreamRegex = regexp.MustCompile(`(?s)<<((?:[^<>]|<<[^<>]*>>)*)>>\s*stream\r?\n`)
+	pdfTextRegex   = regexp.MustCompile(`(?s)BT(.*?)ET`)
+	pdfShowRegex   = regexp.MustCompile(`(?s)\[((?:\\.|[^\]\\])*)\]\s*TJ|(\((?:\\.|[^\\)])*\))\s*(?:Tj|'|")`)
+	pdfStringRegex = regexp.MustCompile(`\((?:\\.|[^\\)])*\)`)
+	pdfUnescaper   = strings.NewReplacer(`\n`, "\n", `\r`, "\r", `\t`, "\t", `\(`, "(", `\)`, ")", `\\`, `\`)
+)
+
+// ExtractText returns the text of every content stream, one shown string or TJ array per word run
+func (BasicPDFExtractor) ExtractText(pdf []byte) (string, error) {
+	var parts []string
+	for _, loc := range pdfStreamRegex.FindAllSubmatchIndex(pdf, -1) {
+		start := loc[1]
+		end := bytes.Index(pdf[start:], []byte("endstream"))
+		if end < 0 {
+			break
+		}
+		data := pdf[start : start+end]
+
+		if bytes.Contains(pdf[loc[2]:loc[3]], []byte("/FlateDecode")) {
+			zr, err := zlib.NewReader(bytes.NewReader(data))
+			if err != nil {
+				continue
+			}
+			data, err = ioutil.ReadAll(zr)
+			zr.Close()
+			if err != nil {
+				continue
+			}
+		}
+
+		for _, block := range pdfTextRegex.FindAllSubmatch(data, -1) {
+			for _, show := range pdfShowRegex.FindAllSubmatch(block[1], -1) {
+				var run strings.Builder
+				for _, s := range pdfStringRegex.FindAll(append(show[1], show[2]...), -1) {
+					run.WriteString(pdfUnescaper.Replace(string(s[1 : len(s)-1])))
+				}
+				parts = append(parts, run.String())
+			}
+		}
+	}
+	return strings.Join(parts, " "), nil
+}
+
+// isPDF reports whether the response is a pdf, by its content type or failing that its magic number
+func isPDF(res *http.Response, body []byte) bool {
+	if t, _, err := mime.ParseMediaType(res.Header.Get("Content-Type")); err == nil && t == "application/pdf" {
+		return true
+	}
+	return bytes.HasPrefix(body, []byte("%PDF-"))
+}
+
+// pdfText swaps a pdf body for its text when a PDFExtractor is set
+func (sc *Scanner) pdfText(res *http.Response, body []byte) ([]byte, error) {
+	if sc.PDFExtractor == nil || !isPDF(res, body) {
+		return body, nil
+	}
+	text, err := sc.PDFExtractor.ExtractText(body)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(text), nil
+}
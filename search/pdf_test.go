@@ -0,0 +1,85 @@
+package search
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// testPDF builds a one page pdf whose content stream is flate compressed, the xref table is left out since the
+// extractor never reads it. A repeated comment makes sure the stream really is compressed rather than stored
+func testPDF(t *testing.T, content string) []byte {
+	var stream bytes.Buffer
+	zw := zlib.NewWriter(&stream)
+	if _, err := zw.Write([]byte("% " + strings.Repeat("filler ", 50) + "\n" + content)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var pdf bytes.Buffer
+	pdf.WriteString("%PDF-1.4\n")
+	pdf.WriteString("1 0 obj << /Type /Catalog /Pages 2 0 R >> endobj\n")
+	pdf.WriteString("2 0 obj << /Type /Pages /Kids [3 0 R] /Count 1 >> endobj\n")
+	pdf.WriteString("3 0 obj << /Type /Page /Parent 2 0 R /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >> endobj\n")
+	fmt.Fprintf(&pdf, "4 0 obj << /Length %d /Filter /FlateDecode >>\nstream\n", stream.Len())
+	pdf.Write(stream.Bytes())
+	pdf.WriteString("\nendstream\nendobj\n")
+	pdf.WriteString("5 0 obj << /Type /Font /Subtype /Type1 /BaseFont /Helvetica >> endobj\n")
+	pdf.WriteString("trailer << /Root 1 0 R >>\n%%EOF\n")
+	return pdf.Bytes()
+}
+
+func TestBasicPDFExtractor(t *testing.T) {
+	pdf := testPDF(t, "BT /F1 12 Tf 72 712 Td (Annual report \\(2019\\)) Tj 0 -14 Td [(Please sign) -250 ( up)] TJ ET")
+	text, err := BasicPDFExtractor{}.ExtractText(pdf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Annual report (2019) Please sign up"; text != want {
+		t.Errorf("expected %q got %q", want, text)
+	}
+}
+
+func TestSearchPDF(t *testing.T) {
+	pdf := testPDF(t, "BT /F1 12 Tf 72 712 Td (Please sign up) Tj ET")
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Write(pdf)
+	}))
+	defer ts.Close()
+
+	sc := NewScanner(1, 0, false, "")
+	res, err := sc.Match(context.Background(), ts.URL, "sign up")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Found {
+		t.Error("the compressed pdf should not match without an extractor")
+	}
+
+	sc.PDFExtractor = BasicPDFExtractor{}
+	if res, err = sc.Match(context.Background(), ts.URL, "sign up"); err != nil {
+		t.Fatal(err)
+	}
+	if !res.Found {
+		t.Error("expected the keyword to be found in the pdf text")
+	}
+
+	errExtract := errors.New("encrypted")
+	sc.PDFExtractor = failingExtractor{errExtract}
+	if _, err = sc.Match(context.Background(), ts.URL, "sign up"); err != errExtract {
+		t.Errorf("expected the extractor error, got %v", err)
+	}
+}
+
+type failingExtractor struct{ err error }
+
+func (f failingExtractor) ExtractText([]byte) (string, error) { return "", f.err }
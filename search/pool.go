@@ -0,0 +1,48 @@
+package search
+
+import "bytes"
+
+// maxPooledBuffer is the largest buffer put back in the pool, one huge page shouldn't hold on to its memory for the
+// life of the scanner
+const maxPooledBuffer = 4 << 20
+
+// bufferPool keeps up to its capacity in buffers that bodies are read into, so a long run reuses them rather than
+// growing a fresh one for every page
+type bufferPool chan *bytes.Buffer
+
+// get returns a pooled buffer, or a new one when the pool is empty
+func (p bufferPool) get() *bytes.Buffer {
+	select {
+	case b := <-p:
+		return b
+	default:
+		return new(bytes.Buffer)
+	}
+}
+
+// put empties b and keeps it for the next get unless the pool is full or b grew past maxPooledBuffer
+func (p bufferPool) put(b *bytes.Buffer) {
+	if b.Cap() > maxPooledBuffer {
+		return
+	}
+	b.Reset()
+	select {
+	case p <- b:
+	default:
+	}
+}
+
+// buffers is the scanner's pool, sized by BufferPoolSize the first time it is used
+func (sc *Scanner) buffers() bufferPool {
+	sc.poolOnce.Do(func() {
+		size := sc.BufferPoolSize
+		if size == 0 {
+			size = cap(sc.Semaphore)
+		}
+		if size < 0 {
+			size = 0
+		}
+		sc.pool = make(bufferPool, size)
+	})
+	return sc.pool
+}
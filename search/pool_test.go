@@ -0,0 +1,34 @@
+package search
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestBufferPool(t *testing.T) {
+	sc := NewScanner(2, 0, false, "")
+	if got := cap(sc.buffers()); got != 2 {
+		t.Fatalf("default pool size = %d, want the concurrency 2", got)
+	}
+
+	pool := make(bufferPool, 1)
+	b := pool.get()
+	b.WriteString("page")
+	pool.put(b)
+	if got := pool.get(); got != b || got.Len() != 0 {
+		t.Errorf("get after put = %p with %d bytes, want the emptied %p", got, got.Len(), b)
+	}
+
+	big := bytes.NewBuffer(make([]byte, 0, maxPooledBuffer+1))
+	pool.put(big)
+	if got := pool.get(); got == big {
+		t.Error("a buffer over maxPooledBuffer was kept")
+	}
+
+	off := NewScanner(2, 0, false, "")
+	off.BufferPoolSize = -1
+	off.buffers().put(new(bytes.Buffer))
+	if got := len(off.buffers()); got != 0 {
+		t.Errorf("pool with BufferPoolSize -1 kept %d buffers", got)
+	}
+}
@@ -0,0 +1,85 @@
+package search
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	log "github.com/marcsantiago/logger"
+)
+
+// Probe checks that the URL answers without searching it, the Result has the StatusCode and Duration and Found is
+// always false. A HEAD request is sent first and a GET when the server doesn't take HEAD, plain http falls back to
+// https like Search. The Result is saved like a search's and also returned, urls that can't be reached are errors
+func (sc *Scanner) Probe(URL string) (Result, error) {
+	return sc.ProbeContext(context.Background(), URL)
+}
+
+// ProbeContext is Probe bound to ctx, the id set with WithID is the ID of the Result
+func (sc *Scanner) ProbeContext(ctx context.Context, URL string) (r Result, err error) {
+	defer sc.markCompleted(1)
+	id, _ := ctx.Value(idKey{}).(string)
+	defer func() {
+		if err != nil {
+			sc.fail(Result{URL: URL, ID: id}, err)
+		}
+	}()
+
+	URL, err = sc.normalize(URL)
+	if err != nil {
+		if sc.Logging {
+			log.Error(logkey, "could not normalize url", "error", err)
+		}
+		return Result{}, err
+	}
+	// a probe looks for no keyword
+	if !sc.firstSearch(URL, "") {
+		return Result{}, nil
+	}
+	defer sc.forgetOnError(&err, URL, "")
+
+	release, err := sc.acquireContext(ctx, URL)
+	if err != nil {
+		return Result{}, err
+	}
+	defer release()
+
+	start := time.Now()
+	res, URL, err := sc.probe(ctx, URL)
+	if err != nil && !strings.Contains(URL, "https:") {
+		URL = strings.Replace(URL, "http", "https", 1)
+		res, URL, err = sc.probe(ctx, URL)
+	}
+	if err != nil {
+		return Result{}, err
+	}
+
+	r = Result{URL: URL, Duration: time.Since(start), ID: id}
+	sc.describeResponse(&r, res)
+	sc.saveResult(r)
+	return r, nil
+}
+
+// probe sends a HEAD, or a GET when HEAD isn't allowed or fails, and closes the body straight away
+func (sc *Scanner) probe(ctx context.Context, URL string) (*http.Response, string, error) {
+	if sc.Fetcher != nil {
+		res, _, URL, err := sc.fetchWith(ctx, URL)
+		return res, URL, err
+	}
+
+	res, err := sc.doMethod(ctx, http.MethodHead, URL)
+	if err == nil && res.StatusCode != http.StatusMethodNotAllowed && res.StatusCode != http.StatusNotImplemented {
+		res.Body.Close()
+		return res, URL, nil
+	}
+	if err == nil {
+		res.Body.Close()
+	}
+
+	if res, err = sc.do(ctx, URL); err != nil {
+		return nil, URL, err
+	}
+	res.Body.Close()
+	return res, URL, nil
+}
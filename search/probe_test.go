@@ -0,0 +1,60 @@
+package search
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProbe(t *testing.T) {
+	var methods []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/live", func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+	})
+	mux.HandleFunc("/get-only", func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	dead := httptest.NewServer(http.NotFoundHandler())
+	deadURL := dead.URL
+	dead.Close()
+
+	sc := NewScanner(1, 0, false, "")
+	sc.SaveErrors = true
+	for _, test := range []struct {
+		path    string
+		status  int
+		methods []string
+	}{
+		{"/live", http.StatusOK, []string{http.MethodHead}},
+		{"/get-only", http.StatusOK, []string{http.MethodHead, http.MethodGet}},
+		{"/missing", http.StatusNotFound, nil},
+	} {
+		methods = nil
+		r, err := sc.Probe(ts.URL + test.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.StatusCode != test.status || r.Found || r.Duration <= 0 {
+			t.Errorf("%s: expected status %d and a duration, got %+v", test.path, test.status, r)
+		}
+		if test.methods != nil && len(methods) != len(test.methods) {
+			t.Errorf("%s: expected %v requests got %v", test.path, test.methods, methods)
+		}
+	}
+
+	if _, err := sc.Probe(deadURL); err == nil {
+		t.Error("expected a dead endpoint to error")
+	}
+
+	results := sc.Results
+	if len(results) != 4 || results[3].Error == "" {
+		t.Errorf("expected every probe saved with the dead one as an error, got %+v", results)
+	}
+}
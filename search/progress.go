@@ -0,0 +1,32 @@
+package search
+
+import "sync/atomic"
+
+// SetTotal sets how many urls the caller is about to hand to the scanner so progress can be shown as Completed/Total
+func (sc *Scanner) SetTotal(n int) {
+	atomic.StoreInt64(&sc.total, int64(n))
+}
+
+// Total returns the value passed to SetTotal
+func (sc *Scanner) Total() int {
+	return int(atomic.LoadInt64(&sc.total))
+}
+
+// Completed returns how many urls have been fully handled, successful or not. Each call to Search, SearchForEmail or
+// SearchWithRequest counts once no matter how many pages its crawl visits, SearchSeeds counts once per seed
+func (sc *Scanner) Completed() int {
+	return int(atomic.LoadInt64(&sc.completed))
+}
+
+// Requests returns how many requests have been sent, retries, robots.txt and link discovery included, divide it by
+// the time taken for the request rate
+func (sc *Scanner) Requests() int {
+	return int(atomic.LoadInt64(&sc.requests))
+}
+
+func (sc *Scanner) markCompleted(n int) {
+	done := atomic.AddInt64(&sc.completed, int64(n))
+	if sc.OnProgress != nil && n > 0 {
+		sc.OnProgress(int(done), sc.Total())
+	}
+}
@@ -0,0 +1,97 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCompleted(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><p>sign up</p></body></html>`)
+	}))
+	defer ts.Close()
+
+	urls := []string{ts.URL, ts.URL + "/a", ts.URL + "/b", "", ts.URL + "/c"}
+	sc := NewScanner(2, 0, false, "sign up")
+	sc.SetTotal(len(urls))
+
+	var wg sync.WaitGroup
+	for _, u := range urls {
+		wg.Add(1)
+		go func(u string) {
+			defer wg.Done()
+			sc.Search(u)
+		}(u)
+	}
+	wg.Wait()
+
+	if sc.Total() != len(urls) {
+		t.Errorf("expected total %d got %d", len(urls), sc.Total())
+	}
+
+	// the empty url errors but still counts as done
+	if sc.Completed() != len(urls) {
+		t.Errorf("expected %d completed got %d", len(urls), sc.Completed())
+	}
+}
+
+func TestOnProgress(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><p>sign up</p></body></html>`)
+	}))
+	defer ts.Close()
+
+	var (
+		mxt   sync.Mutex
+		calls int
+		max   int
+	)
+	sc := NewScanner(2, 0, false, "sign up")
+	sc.SetTotal(3)
+	sc.OnProgress = func(done, total int) {
+		mxt.Lock()
+		defer mxt.Unlock()
+		calls++
+		if done > max {
+			max = done
+		}
+		if total != 3 {
+			t.Errorf("expected total 3, got %d", total)
+		}
+	}
+	if err := sc.SearchBatch(context.Background(), []string{ts.URL, ts.URL + "/a", ts.URL + "/b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	mxt.Lock()
+	defer mxt.Unlock()
+	if calls != 3 || max != 3 {
+		t.Errorf("expected 3 calls reaching 3 done, got %d calls reaching %d", calls, max)
+	}
+}
+
+func TestRequests(t *testing.T) {
+	var seen int64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&seen, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	sc := NewScanner(1, 0, false, "sign up")
+	sc.Retries = 2
+	sc.RetryBackoff = time.Millisecond
+	for _, u := range []string{ts.URL + "/a", ts.URL + "/b"} {
+		sc.Search(u)
+	}
+	// every retry is a request of its own
+	if sc.Requests() != int(atomic.LoadInt64(&seen)) || sc.Requests() < 6 {
+		t.Errorf("expected every request the server saw, at least 6, to be counted, got %d of %d", sc.Requests(), seen)
+	}
+}
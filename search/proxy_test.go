@@ -0,0 +1,77 @@
+package search
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// proxyServer answers every request it is sent as a proxy, recording the urls asked for
+func proxyServer(status int, seen *[]string, mxt *sync.Mutex) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mxt.Lock()
+		*seen = append(*seen, r.URL.String())
+		mxt.Unlock()
+		w.WriteHeader(status)
+		fmt.Fprint(w, "<html><body><p>sign up</p></body></html>")
+	}))
+}
+
+func TestProxyPool(t *testing.T) {
+	var (
+		mxt        sync.Mutex
+		good, bad  []string
+		goodServer = proxyServer(http.StatusOK, &good, &mxt)
+		badServer  = proxyServer(http.StatusTooManyRequests, &bad, &mxt)
+	)
+	defer goodServer.Close()
+	defer badServer.Close()
+
+	pool, err := NewProxyPool([]string{goodServer.URL, badServer.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool.MaxFailures = 2
+
+	sc := NewScanner(1, 0, false, "sign up")
+	if err := sc.UseProxyPool(pool); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 6; i++ {
+		if err := sc.Search(fmt.Sprintf("http://example.com/%d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mxt.Lock()
+	defer mxt.Unlock()
+	// the pages alternate between the proxies until the bad one has failed twice
+	if len(bad) != 2 || len(good) != 4 {
+		t.Errorf("expected 4 requests through the good proxy and 2 through the bad one, got %v and %v", good, bad)
+	}
+	if good[0] != "http://example.com/0" {
+		t.Errorf("expected the proxy to be asked for the page itself, got %s", good[0])
+	}
+	if live := pool.Live(); len(live) != 1 || live[0] != goodServer.URL {
+		t.Errorf("expected only the good proxy left, got %v", live)
+	}
+
+	pool.MaxFailures = 1
+	goodServer.Close()
+	if err := sc.Search("http://example.com/last"); err == nil {
+		t.Errorf("expected the request through the closed proxy to fail")
+	}
+	if err := sc.Search("http://example.com/after"); err != ErrNoProxies {
+		t.Errorf("expected ErrNoProxies once every proxy is evicted, got %v", err)
+	}
+}
+
+func TestNewProxyPool(t *testing.T) {
+	for _, proxies := range [][]string{nil, {"socks5://10.0.0.1:1080"}, {"http://[::1"}} {
+		if _, err := NewProxyPool(proxies); err == nil {
+			t.Errorf("%v: expected an error", proxies)
+		}
+	}
+}
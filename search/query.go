@@ -0,0 +1,325 @@
+package search
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	log "github.com/marcsantiago/logger"
+)
+
+// Query is a boolean query over terms, e.g. `"privacy policy" AND (GDPR OR CCPA) NOT cookie`. Terms are words or
+// quoted phrases matched case insensitively as whole words, phrases match across any whitespace. AND, OR and NOT
+// must be upper case, NOT binds tightest then AND then OR, and terms next to each other are ANDed
+type Query struct {
+	raw   string
+	root  queryNode
+	terms []*queryTerm
+}
+
+// TermMatch is whether one term of a query was found on the page
+type TermMatch struct {
+	Term  string `json:"term"`
+	Found bool   `json:"found"`
+}
+
+// Terms are the terms of a query in the order they appear in it
+type Terms []TermMatch
+
+type queryNode interface {
+	eval(found []bool) bool
+}
+
+type queryTerm struct {
+	i    int
+	term string
+	re   *regexp.Regexp
+}
+
+func (t *queryTerm) eval(found []bool) bool { return found[t.i] }
+
+type queryAnd struct{ l, r queryNode }
+
+func (n queryAnd) eval(found []bool) bool { return n.l.eval(found) && n.r.eval(found) }
+
+type queryOr struct{ l, r queryNode }
+
+func (n queryOr) eval(found []bool) bool { return n.l.eval(found) || n.r.eval(found) }
+
+type queryNot struct{ n queryNode }
+
+func (n queryNot) eval(found []bool) bool { return !n.n.eval(found) }
+
+// ParseQuery parses a boolean query, a *PatternError says what is wrong and where
+func ParseQuery(query string) (*Query, error) {
+	toks, err := lexQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &queryParser{query: query, toks: toks, q: &Query{raw: query}}
+	if len(toks) == 0 {
+		return nil, p.errorf(-1, "empty query")
+	}
+	root, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if t, ok := p.peek(); ok {
+		return nil, p.errorf(t.offset, "unexpected "+t.text)
+	}
+	p.q.root = root
+	return p.q, nil
+}
+
+// String returns the query as it was passed in
+func (q *Query) String() string {
+	return q.raw
+}
+
+// Match reports whether text satisfies the query along with which terms were found in it
+func (q *Query) Match(text string) (bool, Terms) {
+	found := make([]bool, len(q.terms))
+	terms := make(Terms, len(q.terms))
+	for i, t := range q.terms {
+		found[i] = t.re.MatchString(text)
+		terms[i] = TermMatch{Term: t.term, Found: found[i]}
+	}
+	return q.root.eval(found), terms
+}
+
+type queryToken struct {
+	text   string
+	offset int
+	// quoted is set for phrases so "AND" in quotes stays a term
+	quoted bool
+}
+
+func lexQuery(query string) ([]queryToken, error) {
+	var toks []queryToken
+	for i := 0; i < len(query); {
+		switch c := query[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(' || c == ')':
+			toks = append(toks, queryToken{text: string(c), offset: i})
+			i++
+		case c == '"':
+			end := strings.IndexByte(query[i+1:], '"')
+			if end < 0 {
+				return nil, &PatternError{Pattern: query, Offset: i, Message: "missing closing \""}
+			}
+			toks = append(toks, queryToken{text: query[i+1 : i+1+end], offset: i, quoted: true})
+			i += end + 2
+		default:
+			start := i
+			for i < len(query) && !strings.ContainsRune(" \t\n\r()\"", rune(query[i])) {
+				i++
+			}
+			toks = append(toks, queryToken{text: query[start:i], offset: start})
+		}
+	}
+	return toks, nil
+}
+
+type queryParser struct {
+	query string
+	toks  []queryToken
+	pos   int
+	q     *Query
+}
+
+func (p *queryParser) errorf(offset int, msg string) error {
+	return &PatternError{Pattern: p.query, Offset: offset, Message: msg}
+}
+
+func (p *queryParser) peek() (queryToken, bool) {
+	if p.pos >= len(p.toks) {
+		return queryToken{}, false
+	}
+	return p.toks[p.pos], true
+}
+
+// isOp reports whether the next token is the operator op
+func (p *queryParser) isOp(op string) bool {
+	t, ok := p.peek()
+	return ok && !t.quoted && t.text == op
+}
+
+func (p *queryParser) or() (queryNode, error) {
+	l, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("OR") {
+		p.pos++
+		r, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		l = queryOr{l, r}
+	}
+	return l, nil
+}
+
+func (p *queryParser) and() (queryNode, error) {
+	l, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if p.isOp("AND") {
+			p.pos++
+		} else if t, ok := p.peek(); !ok || p.isOp("OR") || (!t.quoted && t.text == ")") {
+			return l, nil
+		}
+		r, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		l = queryAnd{l, r}
+	}
+}
+
+func (p *queryParser) not() (queryNode, error) {
+	if p.isOp("NOT") {
+		p.pos++
+		n, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		return queryNot{n}, nil
+	}
+	return p.term()
+}
+
+func (p *queryParser) term() (queryNode, error) {
+	t, ok := p.peek()
+	if !ok {
+		return nil, p.errorf(len(p.query), "missing term at the end")
+	}
+	if !t.quoted {
+		switch t.text {
+		case "AND", "OR", ")":
+			return nil, p.errorf(t.offset, "missing term before "+t.text)
+		case "(":
+			p.pos++
+			n, err := p.or()
+			if err != nil {
+				return nil, err
+			}
+			if !p.isOp(")") {
+				return nil, p.errorf(t.offset, "missing closing )")
+			}
+			p.pos++
+			return n, nil
+		}
+	}
+	p.pos++
+
+	words := strings.Fields(t.text)
+	if len(words) == 0 {
+		return nil, p.errorf(t.offset, "empty phrase")
+	}
+	first, _ := utf8.DecodeRuneInString(words[0])
+	last, _ := utf8.DecodeLastRuneInString(words[len(words)-1])
+	term := strings.Join(words, " ")
+	for i, w := range words {
+		words[i] = regexp.QuoteMeta(w)
+	}
+	expr := strings.Join(words, `\s+`)
+	if isWordRune(first) {
+		expr = `\b` + expr
+	}
+	if isWordRune(last) {
+		expr += `\b`
+	}
+	re, err := CompilePattern(expr, KeywordOptions{})
+	if err != nil {
+		return nil, err
+	}
+	qt := &queryTerm{i: len(p.q.terms), term: term, re: re}
+	p.q.terms = append(p.q.terms, qt)
+	return qt, nil
+}
+
+// isWordRune reports whether r is a letter, digit or underscore, only then does a word boundary make sense next to it
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// SearchQuery fetches the URL and saves a Result for the query, Keyword is the query string and Terms says which of
+// its terms were found. The query is matched against the same input as a keyword under MatchMode. No links are
+// followed
+func (sc *Scanner) SearchQuery(ctx context.Context, URL string, q *Query) (err error) {
+	defer sc.markCompleted(1)
+	defer func() {
+		if err != nil {
+			id, _ := ctx.Value(idKey{}).(string)
+			sc.fail(Result{URL: URL, Keyword: q.raw, ID: id}, err)
+		}
+	}()
+
+	URL, err = sc.normalize(URL)
+	if err != nil {
+		if sc.Logging {
+			log.Error(logkey, "could not normalize url", "error", err)
+		}
+		return err
+	}
+	if !sc.firstSearch(URL, q.raw) {
+		return nil
+	}
+	defer sc.forgetOnError(&err, URL, q.raw)
+
+	release, err := sc.acquireContext(ctx, URL)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	start := time.Now()
+	res, body, URL, err := sc.fetchResponse(ctx, URL)
+	if err != nil {
+		return err
+	}
+
+	elapsed := time.Since(start)
+
+	r := sc.evaluateQuery(URL, q, body)
+	r.Duration = elapsed
+	sc.describeResponse(&r, res)
+	r.SeedURL = URL
+	r.ID, _ = ctx.Value(idKey{}).(string)
+	sc.saveResult(r)
+	return nil
+}
+
+// evaluateQuery is evaluate for a query
+func (sc *Scanner) evaluateQuery(URL string, q *Query, body []byte) Result {
+	r := Result{URL: URL, Keyword: q.raw, SoftNotFound: sc.softNotFound(body), ThinContent: sc.thinContent(body)}
+	p := newPage(sc.UnicodeForm.normalize(body))
+	p.unescape = sc.HTMLUnescape
+	if sc.RespectCanonical {
+		if canonical := canonicalURL(p.document(), URL); canonical != "" {
+			r.URL = canonical
+		}
+	}
+
+	text, source := string(p.body), SourceRaw
+	switch sc.MatchMode {
+	case MatchText:
+		text, source = p.pageText(), SourceText
+	case MatchPhrase:
+		text, source = p.phraseText(sc.PhraseJoinTags), SourcePhrase
+	}
+	found, terms := q.Match(text)
+	r.Found = found
+	r.Terms = &terms
+	if found {
+		r.MatchSource = source
+	}
+	return r
+}
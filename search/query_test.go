@@ -0,0 +1,107 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	const query = `"privacy policy" AND (GDPR OR CCPA) NOT cookie`
+	q, err := ParseQuery(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		text  string
+		found bool
+		terms []bool
+	}{
+		{"read our Privacy\n Policy, we follow gdpr", true, []bool{true, true, false, false}},
+		{"privacy policy under the ccpa", true, []bool{true, false, true, false}},
+		{"privacy policy under the ccpa, cookies are fine", true, []bool{true, false, true, false}},
+		{"privacy policy under the ccpa and the cookie banner", false, []bool{true, false, true, true}},
+		{"privacy policy only", false, []bool{true, false, false, false}},
+		{"gdpr and ccpa", false, []bool{false, true, true, false}},
+	}
+	for _, tt := range tests {
+		found, terms := q.Match(tt.text)
+		if found != tt.found {
+			t.Errorf("%q: expected found %v", tt.text, tt.found)
+		}
+		for i, term := range []string{"privacy policy", "GDPR", "CCPA", "cookie"} {
+			if terms[i] != (TermMatch{Term: term, Found: tt.terms[i]}) {
+				t.Errorf("%q: expected term %d to be %s found=%v, got %+v", tt.text, i, term, tt.terms[i], terms[i])
+			}
+		}
+	}
+
+	// adjacent terms are ANDed and AND binds tighter than OR
+	q, err = ParseQuery(`sign up OR "AND"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for text, want := range map[string]bool{"sign up": true, "sign": false, "this AND that": true} {
+		if found, _ := q.Match(text); found != want {
+			t.Errorf("%q: expected found %v", text, want)
+		}
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	for query, offset := range map[string]int{
+		``:                  -1,
+		`"privacy policy`:   0,
+		`(GDPR OR CCPA`:     0,
+		`GDPR OR`:           7,
+		`AND cookie`:        0,
+		`GDPR) cookie`:      4,
+		`privacy NOT OR x`:  12,
+		`"" AND privacy`:    0,
+		`gdpr AND (NOT)`:    13,
+		`terms AND OR gdpr`: 10,
+	} {
+		_, err := ParseQuery(query)
+		perr, ok := err.(*PatternError)
+		if !ok {
+			t.Errorf("%q: expected a *PatternError, got %v", query, err)
+			continue
+		}
+		if perr.Offset != offset {
+			t.Errorf("%q: expected offset %d, got %d (%v)", query, offset, perr.Offset, perr)
+		}
+	}
+}
+
+func TestSearchQuery(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><p>Read our <a href="/privacy">Privacy Policy</a>, we comply with GDPR.</p></body></html>`)
+	}))
+	defer ts.Close()
+
+	q, err := ParseQuery(`"privacy policy" AND (GDPR OR CCPA) NOT cookie`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc := NewScanner(1, 0, false, "")
+	sc.MatchMode = MatchText
+	if err := sc.SearchQuery(WithID(context.Background(), "row-1"), ts.URL, q); err != nil {
+		t.Fatal(err)
+	}
+	if len(sc.Results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(sc.Results))
+	}
+
+	r := sc.Results[0]
+	if !r.Found || r.Keyword != q.String() || r.ID != "row-1" || r.MatchSource != SourceText {
+		t.Errorf("expected a found text match for the query, got %+v", r)
+	}
+	want := Terms{{"privacy policy", true}, {"GDPR", true}, {"CCPA", false}, {"cookie", false}}
+	if r.Terms == nil || !reflect.DeepEqual(*r.Terms, want) {
+		t.Errorf("expected terms %+v, got %+v", want, r.Terms)
+	}
+}
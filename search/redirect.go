@@ -0,0 +1,122 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// DefaultMaxRedirects mirrors the limit used by the net/http default client
+const DefaultMaxRedirects = 10
+
+// ErrRedirectLoop is returned when a page redirects back to a URL already visited in the same request
+type ErrRedirectLoop struct {
+	// Chain is every URL hit in order, ending with the URL that closed the loop
+	Chain []string
+}
+
+func (e *ErrRedirectLoop) Error() string {
+	return fmt.Sprintf("redirect loop detected: %s", strings.Join(e.Chain, " -> "))
+}
+
+// Redirects is the urls of a redirected fetch in the order they were requested
+type Redirects []string
+
+// redirectChain walks back from the final response through the redirect responses that led to it, nil when
+// RecordRedirects is off or there were no redirects
+func (sc *Scanner) redirectChain(res *http.Response) *Redirects {
+	if !sc.RecordRedirects || res == nil || res.Request == nil || res.Request.Response == nil {
+		return nil
+	}
+
+	var chain Redirects
+	for req := res.Request; req != nil; {
+		chain = append(chain, req.URL.String())
+		if req.Response == nil {
+			break
+		}
+		req = req.Response.Request
+	}
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return &chain
+}
+
+// checkRedirect is used as the client's CheckRedirect, it stops on loops and once MaxRedirects has been reached.
+// A 3xx without a Location header is never passed here, the client returns that response as is
+func (sc *Scanner) checkRedirect(req *http.Request, via []*http.Request) error {
+	chain := make([]string, 0, len(via)+1)
+	for _, r := range via {
+		chain = append(chain, r.URL.String())
+	}
+
+	// http://site and http://site/ are the same page
+	next := req.URL.String()
+	for _, prev := range chain {
+		if strings.TrimSuffix(prev, "/") == strings.TrimSuffix(next, "/") {
+			return &ErrRedirectLoop{Chain: append(chain, next)}
+		}
+	}
+
+	if len(via) >= sc.MaxRedirects {
+		return fmt.Errorf("stopped after %d redirects", sc.MaxRedirects)
+	}
+	return nil
+}
+
+// metaRefreshRegex pulls the url out of a refresh content such as `0; url='/next'`
+var metaRefreshRegex = regexp.MustCompile(`(?i)^\s*[\d.]*\s*[;,]\s*(?:url\s*=\s*)?['"]?([^'"]+?)['"]?\s*$`)
+
+// metaRefreshURL returns the target of the page's meta refresh resolved against pageURL, empty when there isn't one
+func metaRefreshURL(body []byte, pageURL string) string {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return ""
+	}
+
+	var target string
+	doc.Find("meta[http-equiv]").EachWithBreak(func(i int, s *goquery.Selection) bool {
+		if equiv, _ := s.Attr("http-equiv"); !strings.EqualFold(strings.TrimSpace(equiv), "refresh") {
+			return true
+		}
+		content, _ := s.Attr("content")
+		if m := metaRefreshRegex.FindStringSubmatch(content); m != nil {
+			target, _ = resolveURL(pageURL, strings.TrimSpace(m[1]))
+		}
+		return false
+	})
+	return target
+}
+
+// followMetaRefresh follows meta refreshes from the fetched page until a page without one, loops and more than
+// MaxRedirects refreshes are errors just like http redirects
+func (sc *Scanner) followMetaRefresh(ctx context.Context, res *http.Response, body []byte, URL string) (*http.Response, []byte, string, error) {
+	chain := []string{URL}
+	for {
+		next := metaRefreshURL(body, URL)
+		if next == "" {
+			return res, body, URL, nil
+		}
+
+		for _, prev := range chain {
+			if strings.TrimSuffix(prev, "/") == strings.TrimSuffix(next, "/") {
+				return nil, nil, URL, &ErrRedirectLoop{Chain: append(chain, next)}
+			}
+		}
+		if len(chain) > sc.MaxRedirects {
+			return nil, nil, URL, fmt.Errorf("stopped after %d meta refreshes", sc.MaxRedirects)
+		}
+		chain = append(chain, next)
+
+		var err error
+		if res, body, URL, err = sc.get(ctx, next); err != nil {
+			return nil, nil, URL, err
+		}
+	}
+}
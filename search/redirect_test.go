@@ -0,0 +1,151 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestRedirectLoop(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/b", http.StatusFound)
+	})
+	mux.HandleFunc("/b", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/", http.StatusFound)
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	sc := NewScanner(1, 0, false, "")
+	_, err := sc.Match(context.Background(), ts.URL, "anything")
+
+	var loop *ErrRedirectLoop
+	if !errors.As(err, &loop) {
+		t.Fatalf("expected ErrRedirectLoop got %v", err)
+	}
+
+	want := []string{ts.URL, ts.URL + "/b", ts.URL + "/"}
+	if len(loop.Chain) != len(want) {
+		t.Fatalf("expected chain %v got %v", want, loop.Chain)
+	}
+	for i := range want {
+		if loop.Chain[i] != want[i] {
+			t.Errorf("chain[%d] expected %s got %s", i, want[i], loop.Chain[i])
+		}
+	}
+}
+
+func TestMissingLocation(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusFound)
+		w.Write([]byte("moved somewhere"))
+	}))
+	defer ts.Close()
+
+	sc := NewScanner(1, 0, false, "")
+	res, err := sc.Match(context.Background(), ts.URL, "moved")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !res.Found {
+		t.Errorf("the body of a 3xx without a location should still be searched")
+	}
+}
+
+func TestFollowMetaRefresh(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/old":
+			fmt.Fprint(w, `<html><head><meta http-equiv="Refresh" content="0; URL='/new'"></head><body>moved</body></html>`)
+		case "/new":
+			fmt.Fprint(w, "<html><body><p>sign up</p></body></html>")
+		case "/loop":
+			fmt.Fprint(w, `<html><head><meta http-equiv="refresh" content="0;url=/loop2"></head></html>`)
+		case "/loop2":
+			fmt.Fprint(w, `<html><head><meta http-equiv="refresh" content="0;url=/loop"></head></html>`)
+		}
+	}))
+	defer ts.Close()
+
+	sc := NewScanner(1, 0, false, "")
+	res, err := sc.Match(context.Background(), ts.URL+"/old", "sign up")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Found {
+		t.Error("the refresh should not be followed by default")
+	}
+
+	sc.FollowMetaRefresh = true
+	if res, err = sc.Match(context.Background(), ts.URL+"/old", "sign up"); err != nil {
+		t.Fatal(err)
+	}
+	if !res.Found || res.URL != ts.URL+"/new" {
+		t.Errorf("expected the keyword to be found on the refresh target, got %+v", res)
+	}
+
+	_, err = sc.Match(context.Background(), ts.URL+"/loop", "sign up")
+	var loop *ErrRedirectLoop
+	if !errors.As(err, &loop) {
+		t.Errorf("expected a refresh loop to be an ErrRedirectLoop, got %v", err)
+	}
+}
+
+func TestMetaRefreshURL(t *testing.T) {
+	for content, want := range map[string]string{
+		`0;url=/next`:                 "http://example.com/next",
+		`5; URL="http://other.com/x"`: "http://other.com/x",
+		`0, url=page.html`:            "http://example.com/page.html",
+		`30`:                          "",
+	} {
+		body := fmt.Sprintf(`<meta http-equiv="refresh" content='%s'>`, content)
+		if got := metaRefreshURL([]byte(body), "http://example.com/"); got != want {
+			t.Errorf("%s: expected %q got %q", content, want, got)
+		}
+	}
+}
+
+func TestRecordRedirects(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("/a", http.RedirectHandler("/b", http.StatusMovedPermanently))
+	mux.Handle("/b", http.RedirectHandler("/c", http.StatusFound))
+	mux.HandleFunc("/c", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<p>sign up</p>")
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	sc := NewScanner(1, 0, false, "")
+	r, err := sc.Match(context.Background(), ts.URL+"/a", "sign up")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.RedirectChain != nil {
+		t.Errorf("expected no chain unless asked for, got %v", *r.RedirectChain)
+	}
+
+	sc.RecordRedirects = true
+	for _, stop := range []bool{false, true} {
+		sc.StopOnFirstMatch = stop
+		if r, err = sc.Match(context.Background(), ts.URL+"/a", "sign up"); err != nil {
+			t.Fatal(err)
+		}
+		want := Redirects{ts.URL + "/a", ts.URL + "/b", ts.URL + "/c"}
+		if r.RedirectChain == nil || !reflect.DeepEqual(*r.RedirectChain, want) {
+			t.Errorf("expected the chain %v got %v, StopOnFirstMatch %v", want, r.RedirectChain, stop)
+		}
+
+		if r, err = sc.Match(context.Background(), ts.URL+"/c", "sign up"); err != nil {
+			t.Fatal(err)
+		}
+		if r.RedirectChain != nil {
+			t.Errorf("expected no chain without redirects, got %v", *r.RedirectChain)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+// Package report renders search results as a standalone html page that can be shared with people who won't read csv
+package report
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/marcsantiago/search_keyword/search"
+)
+
+// Options change how the report is rendered
+type Options struct {
+	// Title is the heading of the page, defaults to "Search report"
+	Title string
+	// ContextSeparator joins the values of a list context such as emails, defaults to search.DefaultContextSeparator
+	ContextSeparator string
+	// Now is the time the report says it was generated at, defaults to time.Now
+	Now time.Time
+}
+
+type row struct {
+	URL      string
+	Keyword  string
+	Found    bool
+	Status   int
+	Context  template.HTML
+	Duration time.Duration
+	Error    string
+}
+
+type page struct {
+	Title     string
+	Generated string
+	Total     int
+	Found     int
+	Rows      []row
+}
+
+// Write renders the results as a single html page with no outside assets. The table sorts by any column when its
+// header is clicked, rows are colored by whether the keyword was found and the keyword is highlighted in each context
+func Write(w io.Writer, results search.Results, opts Options) error {
+	p := page{Title: opts.Title, Total: len(results)}
+	if p.Title == "" {
+		p.Title = "Search report"
+	}
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	p.Generated = now.Format(time.RFC1123)
+	sep := opts.ContextSeparator
+	if sep == "" {
+		sep = search.DefaultContextSeparator
+	}
+
+	// results usually share a handful of keywords, compile each once
+	patterns := make(map[string]*regexp.Regexp)
+	for _, r := range results {
+		var keyword string
+		if r.Keyword != nil {
+			keyword = fmt.Sprint(r.Keyword)
+		}
+		re, ok := patterns[keyword]
+		if !ok {
+			re = highlightPattern(keyword)
+			patterns[keyword] = re
+		}
+		if r.Found {
+			p.Found++
+		}
+		p.Rows = append(p.Rows, row{
+			URL:      r.URL,
+			Keyword:  keyword,
+			Found:    r.Found,
+			Status:   r.StatusCode,
+			Context:  highlight(search.ContextString(r.Context, sep), re),
+			Duration: r.Duration,
+			Error:    r.Error,
+		})
+	}
+	return reportTemplate.Execute(w, p)
+}
+
+// highlightPattern compiles the keyword the way the scanner does, a keyword that isn't a valid regex is matched
+// literally and an empty one highlights nothing
+func highlightPattern(keyword string) *regexp.Regexp {
+	if keyword == "" {
+		return nil
+	}
+	re, err := search.CompilePattern(keyword, search.KeywordOptions{})
+	if err != nil {
+		re, _ = search.CompilePattern(keyword, search.KeywordOptions{Literal: true})
+	}
+	return re
+}
+
+// highlight escapes the context and wraps every match of re in a <mark>
+func highlight(context string, re *regexp.Regexp) template.HTML {
+	if re == nil {
+		return template.HTML(html.EscapeString(context))
+	}
+	var b strings.Builder
+	last := 0
+	for _, loc := range re.FindAllStringIndex(context, -1) {
+		if loc[0] == loc[1] {
+			continue
+		}
+		b.WriteString(html.EscapeString(context[last:loc[0]]))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(context[loc[0]:loc[1]]))
+		b.WriteString("</mark>")
+		last = loc[1]
+	}
+	b.WriteString(html.EscapeString(context[last:]))
+	return template.HTML(b.String())
+}
+
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border: 1px solid #ccc; padding: 0.4em 0.6em; text-align: left; vertical-align: top; }
+th { background: #eee; cursor: pointer; user-select: none; }
+th.asc::after { content: " \25B2"; }
+th.desc::after { content: " \25BC"; }
+tr.found td { background: #e6f4e6; }
+tr.missing td { background: #fbeaea; }
+td.context { font-family: monospace; white-space: pre-wrap; word-break: break-all; }
+mark { background: #ffe066; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{.Found}} of {{.Total}} urls matched, generated {{.Generated}}</p>
+<table id="results">
+<thead>
+<tr><th>URL</th><th>Keyword</th><th>Found</th><th>Status</th><th>Context</th><th>Duration</th><th>Error</th></tr>
+</thead>
+<tbody>
+{{- range .Rows}}
+<tr class="{{if .Found}}found{{else}}missing{{end}}">
+<td><a href="{{.URL}}">{{.URL}}</a></td>
+<td>{{.Keyword}}</td>
+<td>{{.Found}}</td>
+<td data-sort="{{.Status}}">{{if .Status}}{{.Status}}{{end}}</td>
+<td class="context">{{.Context}}</td>
+<td data-sort="{{.Duration.Nanoseconds}}">{{.Duration}}</td>
+<td>{{.Error}}</td>
+</tr>
+{{- end}}
+</tbody>
+</table>
+<script>
+document.querySelectorAll("#results th").forEach(function (th, col) {
+	th.addEventListener("click", function () {
+		var asc = !th.classList.contains("asc");
+		document.querySelectorAll("#results th").forEach(function (h) { h.classList.remove("asc", "desc"); });
+		th.classList.add(asc ? "asc" : "desc");
+		var body = document.querySelector("#results tbody");
+		var rows = Array.prototype.slice.call(body.rows);
+		var key = function (tr) {
+			var td = tr.cells[col];
+			return td.hasAttribute("data-sort") ? Number(td.getAttribute("data-sort")) : td.textContent.toLowerCase();
+		};
+		rows.sort(function (a, b) {
+			var x = key(a), y = key(b);
+			return (x < y ? -1 : x > y ? 1 : 0) * (asc ? 1 : -1);
+		});
+		rows.forEach(function (tr) { body.appendChild(tr); });
+	});
+});
+</script>
+</body>
+</html>
+`))
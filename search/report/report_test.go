@@ -0,0 +1,42 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marcsantiago/search_keyword/search"
+)
+
+func TestWrite(t *testing.T) {
+	results := search.Results{
+		{URL: "http://a.com", Keyword: "sign up", Found: true, StatusCode: 200, Context: `<a href="/join">Sign Up</a>`,
+			Duration: 120 * time.Millisecond},
+		{URL: "http://b.com", Keyword: "sign up", StatusCode: 404},
+		{URL: "http://c.com", Keyword: "(", Found: true, Context: "f(x)"},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, results, Options{Title: "Sign up <pages>"}); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"<title>Sign up &lt;pages&gt;</title>",
+		"2 of 3 urls matched",
+		`<tr class="found">`,
+		`<tr class="missing">`,
+		`&lt;a href=&#34;/join&#34;&gt;<mark>Sign Up</mark>&lt;/a&gt;`,
+		// an invalid regex is highlighted literally
+		"f<mark>(</mark>x)",
+		`<td data-sort="120000000">120ms</td>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in the report", want)
+		}
+	}
+	if strings.Contains(out, `<a href="/join">`) {
+		t.Error("the context should be escaped")
+	}
+}
@@ -0,0 +1,48 @@
+package search
+
+import (
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+)
+
+// DefaultRetryBackoff is the wait before the first retry when RetryBackoff isn't set
+const DefaultRetryBackoff = 200 * time.Millisecond
+
+// isTransient reports whether a request is worth retrying, it timed out or the server answered with a 5xx
+func isTransient(res *http.Response, err error) bool {
+	if err != nil {
+		var netErr net.Error
+		return errors.As(err, &netErr) && netErr.Timeout()
+	}
+	return res.StatusCode >= http.StatusInternalServerError
+}
+
+// retryBackoff is the wait before the given retry, counting from 0. It doubles every retry and RetryJitter of it is
+// random so urls failing together don't retry together
+func (sc *Scanner) retryBackoff(attempt int) time.Duration {
+	backoff := sc.RetryBackoff
+	if backoff <= 0 {
+		backoff = DefaultRetryBackoff
+	}
+	backoff <<= uint(attempt)
+
+	jitter := sc.RetryJitter
+	if jitter > 1 {
+		jitter = 1
+	}
+	if jitter > 0 {
+		backoff -= time.Duration(jitter * rand.Float64() * float64(backoff))
+	}
+	return backoff
+}
+
+// statusOf is the response's status code for logging, 0 when there is no response
+func statusOf(res *http.Response) int {
+	if res == nil {
+		return 0
+	}
+	return res.StatusCode
+}
@@ -0,0 +1,65 @@
+package search
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetries(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch n := atomic.AddInt32(&hits, 1); {
+		case r.URL.Path == "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case n == 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case n == 2:
+			time.Sleep(100 * time.Millisecond)
+		}
+		fmt.Fprint(w, `<html><body><p>sign up</p></body></html>`)
+	}))
+	defer ts.Close()
+
+	sc := NewScanner(1, 0, false, "sign up")
+	sc.Client.Timeout = 50 * time.Millisecond
+	sc.Retries = 2
+	sc.RetryBackoff = time.Millisecond
+	sc.RetryJitter = 0.5
+	if err := sc.Search(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	if len(sc.Results) != 1 || !sc.Results[0].Found {
+		t.Errorf("expected the page to be found after a 503 and a timeout, got %+v", sc.Results)
+	}
+	if n := atomic.LoadInt32(&hits); n != 3 {
+		t.Errorf("expected 3 requests, got %d", n)
+	}
+
+	// a 404 isn't transient so it is only asked for once
+	atomic.StoreInt32(&hits, 10)
+	if err := sc.Search(ts.URL + "/missing"); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&hits); n != 11 {
+		t.Errorf("expected a single request for the 404, got %d", n-10)
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	sc := NewScanner(1, 0, false, "")
+	if d := sc.retryBackoff(2); d != 4*DefaultRetryBackoff {
+		t.Errorf("expected the default backoff to double every retry, got %v", d)
+	}
+
+	sc.RetryBackoff = 100 * time.Millisecond
+	sc.RetryJitter = 0.5
+	for i := 0; i < 20; i++ {
+		if d := sc.retryBackoff(1); d < 100*time.Millisecond || d > 200*time.Millisecond {
+			t.Fatalf("expected a jittered wait between 100ms and 200ms, got %v", d)
+		}
+	}
+}
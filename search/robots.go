@@ -0,0 +1,211 @@
+package search
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// robotsRules are the rules of a robots.txt that apply to the scanner
+type robotsRules struct {
+	rules []robotsRule
+	delay time.Duration
+}
+
+type robotsRule struct {
+	allow bool
+	// length is the length of the path pattern, the longest matching rule wins
+	length int
+	re     *regexp.Regexp
+}
+
+// allowed reports whether the path, with its query, may be fetched. The longest matching rule wins and allow wins a
+// tie, a path no rule matches is allowed
+func (r *robotsRules) allowed(path string) bool {
+	best, allow := -1, true
+	for _, rule := range r.rules {
+		if !rule.re.MatchString(path) {
+			continue
+		}
+		if rule.length > best || (rule.length == best && rule.allow) {
+			best, allow = rule.length, rule.allow
+		}
+	}
+	return allow
+}
+
+// parseRobots reads the groups of a robots.txt for agent, falling back to the * group when no group names it.
+// Groups are matched case insensitively against the start of agent, e.g. a group for searchbot applies to
+// "SearchBot/1.0"
+func parseRobots(body []byte, agent string) *robotsRules {
+	agent = strings.ToLower(agent)
+	var (
+		named, star robotsRules
+		// cur are the groups the lines being read belong to, nil outside a group
+		cur     []*robotsRules
+		inAgent bool
+		hasName bool
+	)
+	s := bufio.NewScanner(bytes.NewReader(body))
+	for s.Scan() {
+		line := s.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		i := strings.IndexByte(line, ':')
+		if i < 0 {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(line[:i]))
+		value := strings.TrimSpace(line[i+1:])
+
+		if key == "user-agent" {
+			// consecutive user-agent lines share the group that follows them
+			if !inAgent {
+				cur = nil
+			}
+			inAgent = true
+			ua := strings.ToLower(value)
+			switch {
+			case ua == "*":
+				cur = append(cur, &star)
+			case ua != "" && agent != "" && strings.HasPrefix(agent, strings.SplitN(ua, "/", 2)[0]):
+				cur = append(cur, &named)
+				hasName = true
+			}
+			continue
+		}
+		inAgent = false
+
+		for _, g := range cur {
+			switch key {
+			case "allow", "disallow":
+				// an empty disallow allows everything
+				if value == "" {
+					continue
+				}
+				g.rules = append(g.rules, robotsRule{allow: key == "allow", length: len(value), re: robotsPattern(value)})
+			case "crawl-delay":
+				if secs, err := strconv.ParseFloat(value, 64); err == nil && secs > 0 {
+					g.delay = time.Duration(secs * float64(time.Second))
+				}
+			}
+		}
+	}
+	if hasName {
+		return &named
+	}
+	return &star
+}
+
+// robotsPattern turns a path pattern into a regex, * matches anything and a trailing $ anchors the end
+func robotsPattern(p string) *regexp.Regexp {
+	anchored := strings.HasSuffix(p, "$")
+	p = strings.TrimSuffix(p, "$")
+	expr := "^" + strings.Replace(regexp.QuoteMeta(p), `\*`, ".*", -1)
+	if anchored {
+		expr += "$"
+	}
+	return regexp.MustCompile(expr)
+}
+
+// robotsCache holds the rules of every host seen, fetched once per host
+type robotsCache struct {
+	mxt   sync.Mutex
+	hosts map[string]*robotsRules
+}
+
+func (c *robotsCache) get(host string) (*robotsRules, bool) {
+	c.mxt.Lock()
+	defer c.mxt.Unlock()
+	r, ok := c.hosts[host]
+	return r, ok
+}
+
+func (c *robotsCache) set(host string, r *robotsRules) {
+	c.mxt.Lock()
+	defer c.mxt.Unlock()
+	if c.hosts == nil {
+		c.hosts = make(map[string]*robotsRules)
+	}
+	c.hosts[host] = r
+}
+
+// robotsFor returns the rules for the URL's host, fetching its robots.txt the first time. A robots.txt that is
+// missing, errors or can't be fetched allows everything
+func (sc *Scanner) robotsFor(ctx context.Context, URL string) *robotsRules {
+	u, err := url.Parse(URL)
+	if err != nil {
+		return &robotsRules{}
+	}
+	if r, ok := sc.robots.get(u.Host); ok {
+		return r
+	}
+
+	r := &robotsRules{}
+	res, err := sc.do(ctx, u.Scheme+"://"+u.Host+"/robots.txt")
+	if err == nil {
+		body, rerr := sc.readBody(res)
+		res.Body.Close()
+		if rerr == nil && res.StatusCode == http.StatusOK {
+			agent := sc.RobotsAgent
+			if agent == "" {
+				agent = sc.UserAgent
+			}
+			r = parseRobots(body, agent)
+		}
+	}
+	sc.robots.set(u.Host, r)
+	return r
+}
+
+// robotsAllowed splits urls into the ones robots.txt lets the scanner fetch and the ones it blocks, everything is
+// allowed unless RespectRobots is set
+func (sc *Scanner) robotsAllowed(ctx context.Context, urls []string) (allowed, blocked []string) {
+	if !sc.RespectRobots {
+		return urls, nil
+	}
+	for _, URL := range urls {
+		u, err := url.Parse(URL)
+		if err != nil || sc.robotsFor(ctx, URL).allowed(u.RequestURI()) {
+			allowed = append(allowed, URL)
+			continue
+		}
+		blocked = append(blocked, URL)
+	}
+	return
+}
+
+// crawlDelay is the Crawl-delay of the host's robots.txt once it has been fetched
+func (sc *Scanner) crawlDelay(host string) time.Duration {
+	if !sc.RespectRobots {
+		return 0
+	}
+	if r, ok := sc.robots.get(host); ok {
+		return r.delay
+	}
+	return 0
+}
+
+// robotsFirst fetches the robots.txt of URL's host with RespectRobots, before the url itself is, so the Crawl-delay
+// applies from the first request
+func (sc *Scanner) robotsFirst(ctx context.Context, URL string) {
+	if sc.RespectRobots {
+		sc.robotsFor(ctx, URL)
+	}
+}
+
+// discover is linksToCheck split by robotsAllowed, the url itself is never blocked. robotsFirst is called on it first
+func (sc *Scanner) discover(ctx context.Context, URL string) (urls, blocked []string) {
+	sc.robotsFirst(ctx, URL)
+	urls = sc.linksToCheck(ctx, URL, sc.DepthLimit)
+	allowed, blocked := sc.robotsAllowed(ctx, urls[1:])
+	return append(urls[:1], allowed...), blocked
+}
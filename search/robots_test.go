@@ -0,0 +1,175 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseRobots(t *testing.T) {
+	body := []byte(`
+# everyone
+User-agent: *
+Disallow: /private
+Allow: /private/open
+Disallow: /*.pdf$
+
+User-agent: SearchBot
+User-agent: other
+Disallow: /
+Allow: /public
+Crawl-delay: 1.5
+`)
+
+	star := parseRobots(body, "")
+	for path, want := range map[string]bool{
+		"/":                 true,
+		"/private":          false,
+		"/private/page":     false,
+		"/private/open/doc": true,
+		"/files/a.pdf":      false,
+		"/files/a.pdf?x=1":  true,
+	} {
+		if got := star.allowed(path); got != want {
+			t.Errorf("*: expected allowed(%q) to be %v", path, want)
+		}
+	}
+	if star.delay != 0 {
+		t.Errorf("expected no crawl delay for *, got %v", star.delay)
+	}
+
+	named := parseRobots(body, "searchbot/2.0")
+	if named.allowed("/private/open") || !named.allowed("/public/page") {
+		t.Errorf("expected the SearchBot group to apply instead of *")
+	}
+	if named.delay != 1500*time.Millisecond {
+		t.Errorf("expected a 1.5s crawl delay, got %v", named.delay)
+	}
+}
+
+func TestRespectRobots(t *testing.T) {
+	var (
+		mxt     sync.Mutex
+		fetched = make(map[string]int)
+	)
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mxt.Lock()
+		fetched[r.URL.Path]++
+		mxt.Unlock()
+		if r.URL.Path == "/robots.txt" {
+			fmt.Fprint(w, "User-agent: *\nDisallow: /admin\nCrawl-delay: 0.05\n")
+			return
+		}
+		fmt.Fprintf(w, `<html><body><a href="%[1]s/admin">admin</a><a href="%[1]s/about">about</a><p>sign up</p></body></html>`, ts.URL)
+	}))
+	defer ts.Close()
+
+	sc := NewScanner(1, 3, false, "sign up")
+	sc.RespectRobots = true
+	start := time.Now()
+	if err := sc.Search(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	// after robots.txt the seed is fetched for its links, then searched, then /about, two waits of the crawl delay
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("expected the crawl delay to space out requests, took %v", elapsed)
+	}
+
+	mxt.Lock()
+	defer mxt.Unlock()
+	if fetched["/admin"] != 0 {
+		t.Errorf("expected /admin not to be fetched")
+	}
+	if fetched["/robots.txt"] != 1 || fetched["/about"] != 1 {
+		t.Errorf("expected robots.txt and /about to be fetched once, got %v", fetched)
+	}
+
+	var blocked int
+	for _, r := range sc.Results {
+		if r.RobotsBlocked {
+			blocked++
+			if r.URL != ts.URL+"/admin" || r.Found {
+				t.Errorf("expected only /admin to be blocked, got %+v", r)
+			}
+		}
+	}
+	if blocked != 1 || len(sc.Results) != 3 {
+		t.Errorf("expected 3 results with 1 blocked, got %+v", sc.Results)
+	}
+}
+
+func TestRespectRobotsFollowingLinks(t *testing.T) {
+	var (
+		mxt     sync.Mutex
+		fetched = make(map[string]int)
+	)
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mxt.Lock()
+		fetched[r.URL.Path]++
+		mxt.Unlock()
+		switch r.URL.Path {
+		case "/robots.txt":
+			fmt.Fprint(w, "User-agent: *\nDisallow: /admin\n")
+		case "/feed":
+			fmt.Fprintf(w, `<?xml version="1.0"?><rss version="2.0"><channel><title>t</title>
+				<item><title>a</title><link>%[1]s/admin</link></item>
+				<item><title>b</title><link>%[1]s/about</link></item></channel></rss>`, ts.URL)
+		default:
+			fmt.Fprintf(w, `<html><body><a href="%[1]s/admin">admin</a><a href="%[1]s/about">about</a><p>sign up</p></body></html>`, ts.URL)
+		}
+	}))
+	defer ts.Close()
+
+	ctx := context.Background()
+	for name, run := range map[string]func(sc *Scanner) error{
+		"Crawl": func(sc *Scanner) error {
+			return sc.Crawl(ctx, []string{ts.URL}, "sign up", 1)
+		},
+		"SearchSeeds": func(sc *Scanner) error {
+			sc.DepthLimit = 1
+			return sc.SearchSeeds(ctx, []string{ts.URL}, "sign up")
+		},
+		"CrawlFeed": func(sc *Scanner) error {
+			return sc.CrawlFeed(ts.URL+"/feed", "sign up")
+		},
+		"SearchPaginated": func(sc *Scanner) error {
+			next := func([]byte) (string, error) { return ts.URL + "/admin", nil }
+			return sc.SearchPaginated(ctx, ts.URL+"/about", "sign up", next, 0)
+		},
+	} {
+		mxt.Lock()
+		fetched = make(map[string]int)
+		mxt.Unlock()
+
+		sc := NewScanner(2, 0, false, "sign up")
+		sc.RespectRobots = true
+		if err := run(sc); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		mxt.Lock()
+		if fetched["/admin"] != 0 || fetched["/robots.txt"] != 1 {
+			t.Errorf("%s: expected robots.txt fetched once and /admin never, got %v", name, fetched)
+		}
+		mxt.Unlock()
+
+		var blocked int
+		for _, r := range sc.Results {
+			if r.RobotsBlocked {
+				blocked++
+				if r.URL != ts.URL+"/admin" || r.Found {
+					t.Errorf("%s: expected only /admin to be blocked, got %+v", name, r)
+				}
+			}
+		}
+		if blocked != 1 {
+			t.Errorf("%s: expected /admin to be saved as blocked once, got %+v", name, sc.Results)
+		}
+	}
+}
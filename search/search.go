@@ -2,17 +2,20 @@
 package search
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"net"
 	"net/http"
 	"net/url"
 	"regexp"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
@@ -29,10 +32,21 @@ var (
 	ErrDomainMissing = fmt.Errorf("url domain e.g .com, .net was missing")
 	// ErrUnresolvedOrTimedOut ...
 	ErrUnresolvedOrTimedOut = fmt.Errorf("url could not be resolved or timed out")
+	// ErrSinkClosed is returned by ChanSink.Write once the sink is closed
+	ErrSinkClosed = fmt.Errorf("sink is closed")
+	// ErrNoProxies is returned for requests made once every proxy of a ProxyPool has been evicted
+	ErrNoProxies = fmt.Errorf("every proxy in the pool has failed")
 	// EmailRegex provides a base email regex for scraping emails
 	EmailRegex      = regexp.MustCompile(`([a-z0-9!#$%&'*+\/=?^_{|}~-]+(?:\.[a-z0-9!#$%&'*+\/=?^_{|}~-]+)*(@|\sat\s)(?:[a-z0-9](?:[a-z0-9-]*[a-z0-9])?(\.|\sdot\s))+[a-z0-9](?:[a-z0-9-]*[a-z0-9])?)`)
 	logkey          = "Scanner"
 	newLineReplacer = strings.NewReplacer("\r\n", "", "\n", "", "\r", "")
+	// DefaultSoftNotFoundPatterns are the patterns used to spot "page not found" pages served with a 200
+	DefaultSoftNotFoundPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)<title>[^<]*(not found|404)[^<]*</title>`),
+		regexp.MustCompile(`(?i)page (was )?not found`),
+		regexp.MustCompile(`(?i)(page|content) (you (are looking for|requested) )?(could not|cannot|can't|can not) be found`),
+		regexp.MustCompile(`(?i)page (does not|doesn't) exist`),
+	}
 )
 
 // Result is the basic return type for Search
@@ -44,6 +58,40 @@ type Result struct {
 	// Found determines whether or not the keyword was matched on the page
 	Found   bool        `json:"found,omitempty"`
 	Context interface{} `json:"context,omitempty"`
+	// SoftNotFound is set when the page came back fine but its body looks like a "page not found" page
+	SoftNotFound bool `json:"soft_not_found,omitempty"`
+	// ThinContent is set when the body is shorter than the scanner's MinBodyBytes, likely a placeholder or error page
+	ThinContent bool `json:"thin_content,omitempty"`
+	// Matches holds where each match sits in the page, only filled in when matching page text. It is a pointer so
+	// Result stays comparable
+	Matches *Matches `json:"matches,omitempty"`
+	// Score is the keyword density of the page text, matches per 1000 words
+	Score float64 `json:"score,omitempty"`
+	// MatchSource is where the keyword was found, one of the Source constants, empty when it wasn't found
+	MatchSource string `json:"match_source,omitempty"`
+	// RedirectChain is every url the fetch went through when it was redirected and RecordRedirects is set, from the
+	// url requested to the one the page came from. It is a pointer so Result stays comparable
+	RedirectChain *Redirects `json:"redirect_chain,omitempty"`
+	// Terms says which terms of the query were found, only set by SearchQuery. It is a pointer so Result stays
+	// comparable
+	Terms *Terms `json:"terms,omitempty"`
+	// RobotsBlocked is set when RespectRobots is on and the site's robots.txt disallows the page, it wasn't fetched
+	RobotsBlocked bool `json:"robots_blocked,omitempty"`
+	// StatusCode is the http status of the response the page came from
+	StatusCode int `json:"status_code,omitempty"`
+	// ContentLength is the size in bytes of the body read, up to MaxBodyBytes. With StopOnFirstMatch the body isn't
+	// read to the end so it is the Content-Length the server sent, -1 when it didn't send one
+	ContentLength int64 `json:"content_length,omitempty"`
+	// Truncated is set when the body was longer than MaxBodyBytes and only its start was matched
+	Truncated bool `json:"truncated,omitempty"`
+	// Duration is how long the page took to fetch, from sending the request until the body was read
+	Duration time.Duration `json:"duration,omitempty"`
+	// ID is the caller's identifier for the url passed to SearchWithID, every page of its crawl carries it
+	ID string `json:"id,omitempty"`
+	// Error is why the url failed, only saved when SaveErrors is set
+	Error string `json:"error,omitempty"`
+	// SeedURL is the url the crawl that found this page started from, the page itself for the seed
+	SeedURL string `json:"seed_url,omitempty"`
 }
 
 // Results is the plural of results which implements the Sort interface. Sorting by URL.  If the slice needs to be sorted then the user can call sort.Sort
@@ -61,8 +109,73 @@ func (slice Results) Swap(i, j int) {
 	slice[i], slice[j] = slice[j], slice[i]
 }
 
+// MatchingURLs returns the urls of the results where the keyword was found, in the order they appear
+func (slice Results) MatchingURLs() []string {
+	var urls []string
+	for _, r := range slice {
+		if r.Found {
+			urls = append(urls, r.URL)
+		}
+	}
+	return urls
+}
+
+// ByURL indexes the results by url for quick lookups. When a url appears more than once, for example because it was
+// searched for several keywords or reached from two seeds, the last one in the slice wins
+func (slice Results) ByURL() map[string]Result {
+	m := make(map[string]Result, len(slice))
+	for _, r := range slice {
+		m[r.URL] = r
+	}
+	return m
+}
+
+// GroupByKeyword splits the results by keyword, the order within each group is kept. Keywords that aren't strings are
+// keyed by their string form e.g a regular expression by its pattern
+func (slice Results) GroupByKeyword() map[string]Results {
+	groups := make(map[string]Results)
+	for _, r := range slice {
+		k := keywordString(r.Keyword)
+		groups[k] = append(groups[k], r)
+	}
+	return groups
+}
+
+// GroupByDomain splits the results by host, lower cased and including any port, the order within each group is kept.
+// Results whose url can't be parsed are grouped under ""
+func (slice Results) GroupByDomain() map[string]Results {
+	groups := make(map[string]Results)
+	for _, r := range slice {
+		var host string
+		if u, err := url.Parse(r.URL); err == nil {
+			host = strings.ToLower(u.Host)
+		}
+		groups[host] = append(groups[host], r)
+	}
+	return groups
+}
+
+func keywordString(keyword interface{}) string {
+	switch k := keyword.(type) {
+	case nil:
+		return ""
+	case string:
+		return k
+	case fmt.Stringer:
+		return k.String()
+	default:
+		return fmt.Sprintf("%v", k)
+	}
+}
+
 // Scanner is the basic structure used to interact with the html content of the page
 type Scanner struct {
+	// progress counters, kept first so they are 64 bit aligned for the atomic package
+	completed int64
+	total     int64
+	errored   int64
+	requests  int64
+
 	// Client is used to make requests
 	Client *http.Client
 	// Semaphore is used to limit the number of goroutines spinning up
@@ -73,14 +186,209 @@ type Scanner struct {
 	Logging bool
 	// DepthLimit used to define depth of search
 	DepthLimit int
+	// IncludePattern if set only links matching it are followed while crawling
+	IncludePattern *regexp.Regexp
+	// ExcludePattern if set links matching it are never followed while crawling
+	ExcludePattern *regexp.Regexp
+	// MaxBodyBytes if above 0 caps how much of a response body is read, so one huge response can't exhaust memory. The
+	// Result of a page that was cut off has Truncated set
+	MaxBodyBytes int64
+	// SearchPrefixBytes if above 0 only matches the keyword against the start of the body, unlike MaxBodyBytes the
+	// whole body is still downloaded and used for finding links
+	SearchPrefixBytes int64
+	// MaxLinksPerPage if above 0 caps how many anchors on a page are looked at while crawling
+	MaxLinksPerPage int
+	// LinkOrdering decides the order links found on a page are looked at in, and so which are kept by MaxLinksPerPage
+	// and the depth limit, defaults to the order they appear in the page
+	LinkOrdering LinkOrdering
+	// LinkSeed seeds the shuffle of LinkShuffled so the same seed picks the same links on every run
+	LinkSeed int64
+	// RespectCanonical saves a page that declares a <link rel="canonical"> under its canonical url, Search and Crawl
+	// then save pages sharing a canonical url once and Crawl treats the canonical url as visited
+	RespectCanonical bool
+	// SoftNotFoundPatterns mark a result as SoftNotFound when any of them match the body, set to nil to turn the check off
+	SoftNotFoundPatterns []*regexp.Regexp
+	// MinBodyBytes if above 0 marks results for bodies shorter than it as ThinContent. It is not checked when
+	// StopOnFirstMatch streams the body
+	MinBodyBytes int64
+	// UnicodeForm normalizes the page and the keyword to NFC or NFKC before matching so the same text written with
+	// different code points, such as a composed é and an e followed by a combining accent, still matches
+	UnicodeForm UnicodeForm
+	// MatchMode decides whether the keyword is matched against the raw html or the page text, defaults to raw
+	MatchMode MatchMode
+	// PhraseJoinTags makes MatchPhrase drop tag boundaries rather than read them as spaces, for words split by inline
+	// tags such as Con<b>nect</b>
+	PhraseJoinTags bool
+	// HTMLUnescape decodes html entities in the page text before matching, so "AT&T" matches a page holding
+	// "AT&amp;amp;T". It only applies to MatchText and MatchPhrase, the parser already decodes entities once there and
+	// this catches the ones escaped twice. Raw matching is left alone since decoding the raw html would turn escaped
+	// markup into tags, use MatchText for entity encoded pages
+	HTMLUnescape bool
+	// RawContext keeps the whitespace of context snippets as it is on the page, by default runs of spaces and tabs are
+	// collapsed to one space and the ends trimmed
+	RawContext bool
+	// ContextMode decides what is saved as the Context of a match, defaults to the surrounding tag
+	ContextMode ContextMode
+	// StopOnFirstMatch stops reading a page as soon as the keyword is found. Context and Score are left empty since
+	// the rest of the page is never read
+	StopOnFirstMatch bool
+	// StreamChunkBytes if above 0 matches raw html pages chunk by chunk as they are read, instead of reading the whole
+	// body first, so memory stays bounded however big the page. Reading stops at the first match and the Context is
+	// taken from the chunk it is in, Score and the other checks that need the whole page are left empty.
+	// It only applies with MatchMode MatchRaw and no BodyTransform, PDFExtractor or Fetcher, and only to pages that
+	// are searched without their links being read: the seed of a search with DepthLimit set, the seeds of SearchSeeds
+	// and every Crawl page are still read whole to find the links, as are the pages of SearchForEmail, SearchMany,
+	// SearchQuery and MatchWithHeaders. Set MaxBodyBytes to bound those too
+	StreamChunkBytes int
+	// StreamOverlapBytes is how much of each chunk is matched again with the next so matches across the boundary are
+	// found, it is the longest match that can straddle two chunks. Defaults to DefaultStreamOverlap
+	StreamOverlapBytes int
+	// BufferPoolSize is how many of the buffers pages are read into are kept to be reused, 0 keeps as many as the
+	// concurrency limit and below 0 keeps none. Buffers that grew past 4MB are never kept
+	BufferPoolSize int
+	// LevelBuffer bounds how many urls of a level Crawl queues and works on at once, defaults to the concurrency limit
+	LevelBuffer int
+	// FailFast makes SearchBatch stop at the first error instead of collecting errors and carrying on
+	FailFast bool
+	// SearchTimeout if above 0 bounds how long Search may spend on a seed, its links included. Client.Timeout on the
+	// other hand bounds each request on its own
+	SearchTimeout time.Duration
+	// KeywordConcurrency is how many keywords SearchMany matches against a page at once, defaults to GOMAXPROCS
+	KeywordConcurrency int
+	// ParallelDepth makes Search fetch the pages found on a seed concurrently instead of one after the other, taking
+	// extra semaphore slots while they are free. Results are still saved in the order the pages were found
+	ParallelDepth bool
+	// FollowMetaRefresh follows <meta http-equiv="refresh"> redirects before matching, whatever their delay, counting
+	// them against MaxRedirects. Javascript redirects are never followed
+	FollowMetaRefresh bool
+	// MaxConcurrentPerHost if above 0 caps how many requests run against any one host at once, the Semaphore still caps
+	// the total. A host's slot is taken before a global one so a slow host can't sit on global slots waiting for its own
+	MaxConcurrentPerHost int
+	// UserAgent if set is sent as the User-Agent of every request instead of Go's default, many sites serve different
+	// or no content to it
+	UserAgent string
+	// Header holds headers sent with every request, e.g. Accept-Language. Use WithHeader for headers of a single call
+	Header http.Header
+	// HostHeader holds headers sent to each host, keyed by host or host:port, they replace the values Header has for
+	// the same names
+	HostHeader map[string]http.Header
+	// Compression asks for gzip, deflate or brotli bodies with an Accept-Encoding header, they are decompressed before
+	// matching. Compressed bodies sent without being asked for are decompressed either way
+	Compression bool
+	// Auth authenticates every request whose host isn't in HostAuth, use it for scans of a single site behind auth as
+	// it is sent to any host the scan reaches
+	Auth Credentials
+	// HostAuth holds the credentials for each host, keyed by host or host:port
+	HostAuth map[string]Credentials
+	// RespectRobots fetches each host's robots.txt once and skips the links it disallows, saving them with
+	// RobotsBlocked set, and waits its Crawl-delay between requests to the host. The url searched is always fetched
+	RespectRobots bool
+	// RobotsAgent is the name looked up in robots.txt, UserAgent when it isn't set. The rules for * are used when no
+	// group names it
+	RobotsAgent string
+	// HostRate if above 0 caps how many requests per second are sent to any one host, e.g. 0.5 is one every two
+	// seconds. It is shared by every search on the scanner, redirects the client follows on its own aren't counted
+	HostRate float64
+	// HostRates replaces HostRate for each host, keyed by host or host:port, 0 takes the host off the limit
+	HostRates map[string]float64
+	// DNSRetries is how many times a request is retried when resolving the host fails, for example on a temporary
+	// SERVFAIL. Hosts that don't exist are never retried
+	DNSRetries int
+	// DNSRetryBackoff is the wait before the first dns retry, it doubles on every further retry. Defaults to
+	// DefaultDNSRetryBackoff
+	DNSRetryBackoff time.Duration
+	// Retries is how many times a request is retried when it times out or the server answers with a 5xx, so a flaky
+	// host doesn't turn into a miss. Once out of retries the last response or error is used as normal
+	Retries int
+	// RetryBackoff is the wait before the first retry, it doubles on every further retry. Defaults to
+	// DefaultRetryBackoff
+	RetryBackoff time.Duration
+	// RetryJitter is the fraction, up to 1, of each retry wait that is random, e.g. 0.5 waits between half and all
+	// of it
+	RetryJitter float64
+	// InsecureHosts are hosts, without the port, whose certificates aren't verified, such as internal hosts with self
+	// signed certificates. Every other host is verified as normal
+	InsecureHosts []string
+	// MaxRedirects is the number of redirects followed before giving up on a page
+	MaxRedirects int
+	// RecordRedirects saves the urls a redirected fetch went through as the RedirectChain of its Result
+	RecordRedirects bool
+	// DebugMatch logs, whether or not Logging is on, how every page was fetched and matched: the compiled regex, the
+	// content type, whether text was extracted, how many bytes were matched and where the matches are
+	DebugMatch bool
 	// Keyword is the keyword being searched for
 	Keyword string
+	// BodyTransform if set preprocesses every fetched page before it is matched, for example to strip boilerplate, pdfs
+	// are handed over as their extracted text. An error is returned as the page's fetch error. Crawl takes links from
+	// the transformed body, Search and SearchSeeds read links from the untouched page. StopOnFirstMatch has no effect
+	// while it is set
+	BodyTransform func([]byte) ([]byte, error)
+	// Fetcher if set fetches every page in place of Client, SearchWithRequest still sends its request with Client
+	Fetcher Fetcher
+	// TranscodeCharset decodes pages served in a charset other than utf-8, declared in the Content-Type header or a
+	// meta tag, to utf-8 before matching so keywords with accents or in other scripts are found
+	TranscodeCharset bool
+	// PDFExtractor if set is used to match pdfs, found by content type or their %PDF- header, against their text
+	// rather than the raw file. BasicPDFExtractor handles simple pdfs without any dependency
+	PDFExtractor PDFExtractor
+	// NormalizeFunc if set replaces NormalizeURL for every url passed to the scanner, for example to force a trailing
+	// slash. It can call NormalizeURL itself and adjust what comes back
+	NormalizeFunc func(URL string) (string, error)
+	// OnResult if set is called with every result as soon as it is saved, it may be called from many goroutines
+	OnResult func(Result)
+	// OnProgress if set is called every time a url is fully handled with Completed and Total, it may be called from
+	// many goroutines and calls can arrive out of order so keep the largest done seen
+	OnProgress func(done, total int)
+	// ContextSeparator joins list contexts, such as the emails found by SearchForEmail, when results are written as
+	// csv by WriteResultsFile and WriteResultsByDomain, defaults to DefaultContextSeparator. Json keeps them as arrays
+	ContextSeparator string
+	// SortResults sorts results by url before they are written out by WriteResultsFile
+	SortResults bool
+	// DedupResults drops repeated url and keyword pairs before results are written out by WriteResultsFile
+	DedupResults bool
+	// DedupURLs searches every url once per keyword for the life of the scanner, compared after normalization, so
+	// duplicates in the input and pages found again by the depth crawl, Crawl or SearchSeeds aren't fetched twice. It
+	// covers every method that saves results, Match and MatchWithHeaders hand theirs back and always fetch. A skipped
+	// url saves no result, while a url is being fetched copies of it are skipped and once its fetch fails it is
+	// searched again the next time it comes up
+	DedupURLs bool
+	// SaveErrors saves a Result with Error set for every url that fails so failures sit next to the matches
+	SaveErrors bool
+	// ExpectedResults if above 0 is how many results Results is allocated room for when the first one is saved, which
+	// saves growing the slice over and over on big runs
+	ExpectedResults int
+	// ResultBatchSize if above 1 makes each Search hold up to that many results before adding them to Results under
+	// one lock, cutting lock contention on deep crawls. OnResult and the sinks still get every result straight away,
+	// Results catches up by the time Search returns
+	ResultBatchSize int
+	// DiscardResults stops results being kept in Results, useful with OnResult to keep memory flat on big runs
+	DiscardResults bool
 	// used internally to lock writing to the map
 	mxt sync.Mutex
+	// sinks receive every saved result, guarded by sinkMxt
+	sinks   []Sink
+	sinkMxt sync.Mutex
+	// tally keeps the counts behind Summary
+	tally tally
+	// hosts holds the per host semaphores for MaxConcurrentPerHost, shared with the scanners made by Share
+	hosts *hostLimiter
+	// pacer spaces out requests to each host for HostRate and robots.txt Crawl-delay, shared like hosts
+	pacer *hostPacer
+	// robots caches the robots.txt rules of every host for RespectRobots, shared like hosts
+	robots *robotsCache
+	// proxies is the pool set by UseProxyPool
+	proxies *ProxyPool
+	// searched holds the urls already searched for DedupURLs
+	searched urlSet
+	// pool holds the read buffers for BufferPoolSize, made once by poolOnce
+	pool     bufferPool
+	poolOnce sync.Once
+	// tlsClient is the client used once InsecureHosts is set, built once by tlsOnce
+	tlsClient *http.Client
+	tlsOnce   sync.Once
 
 	// used to avoid having to compile more than once
-	searchRegex  *regexp.Regexp
-	contextRegex *regexp.Regexp
+	kw *Keyword
 }
 
 // Semaphore ...
@@ -89,6 +397,26 @@ type Semaphore chan struct{}
 func (s Semaphore) release() { <-s }
 func (s Semaphore) load()    { s <- struct{}{} }
 
+// loadContext waits for a slot until ctx is done
+func (s Semaphore) loadContext(ctx context.Context) error {
+	select {
+	case s <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// tryLoad takes a slot only if one is free right away
+func (s Semaphore) tryLoad() bool {
+	select {
+	case s <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
 func inSlice(tar string, s []string) bool {
 	for _, i := range s {
 		if tar == i {
@@ -98,33 +426,82 @@ func inSlice(tar string, s []string) bool {
 	return false
 }
 
-func linksToCheck(baseURL string, limit int) (moreURLS []string) {
+// containsAny reports whether s contains any of the substrings
+func containsAny(s string, substrs []string) bool {
+	for _, sub := range substrs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// linksToCheck collects up to limit links on the page that stay on baseURL, filtered by IncludePattern and ExcludePattern.
+// The page is read through the scanner's client so MaxBodyBytes applies before parsing and at most MaxLinksPerPage anchors are looked at
+func (sc *Scanner) linksToCheck(ctx context.Context, baseURL string, limit int) (moreURLS []string) {
 	moreURLS = []string{baseURL}
 	if limit == 0 {
 		return
 	}
 
-	doc, err := goquery.NewDocument(baseURL)
+	body, err := sc.makeRequest(ctx, baseURL)
+	if err != nil {
+		log.Error(logkey, "could not fetch page for links", "error", err)
+		return
+	}
+	return sc.extractLinks(body, baseURL, limit)
+}
+
+// extractLinks returns baseURL followed by the links in body that stay on baseURL and pass the crawl filters, the list
+// stops growing once it holds limit urls, a limit of 0 or less means no limit
+func (sc *Scanner) extractLinks(body []byte, baseURL string, limit int) (moreURLS []string) {
+	moreURLS = []string{baseURL}
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
 	if err != nil {
 		log.Error(logkey, "could not create doc", "error", err)
 		return
 	}
 
-	doc.Find("body a").Each(func(index int, item *goquery.Selection) {
+	links := doc.Find("body a").Map(func(i int, item *goquery.Selection) string {
 		link, _ := item.Attr("href")
+		return link
+	})
+	sc.orderLinks(links)
+	if sc.MaxLinksPerPage > 0 && len(links) > sc.MaxLinksPerPage {
+		links = links[:sc.MaxLinksPerPage]
+	}
+
+	for _, link := range links {
+		if !sc.followLink(link) {
+			continue
+		}
 		if strings.Contains(link, baseURL) {
 			if !inSlice(link, moreURLS) {
 				moreURLS = append(moreURLS, link)
 			}
 		}
-		if len(moreURLS) >= limit {
-			return
+		if limit > 0 && len(moreURLS) >= limit {
+			break
 		}
-	})
+	}
 	return
 }
 
-func normalizeURL(URL string) (s string, err error) {
+// followLink reports whether a discovered link passes the include and exclude patterns
+func (sc *Scanner) followLink(link string) bool {
+	if sc.IncludePattern != nil && !sc.IncludePattern.MatchString(link) {
+		return false
+	}
+	if sc.ExcludePattern != nil && sc.ExcludePattern.MatchString(link) {
+		return false
+	}
+	return true
+}
+
+// NormalizeURL is the normalization applied to every url before it is fetched. A scheme of http is added when there
+// isn't one and urls without a domain are rejected. The port is kept. The path of a url with a host is kept, even a
+// single segment such as /about, a bare trailing slash is dropped
+func NormalizeURL(URL string) (s string, err error) {
 	if URL == "" {
 		err = ErrURLEmpty
 		return
@@ -136,12 +513,15 @@ func normalizeURL(URL string) (s string, err error) {
 	}
 
 	scheme := u.Scheme
-	path := u.Hostname()
+	path := u.Host
 	if path == "" {
 		path = strings.Replace(u.Path, "/", "", -1)
 	}
 
-	parts := strings.Split(path, ".")
+	parts := strings.Split(u.Hostname(), ".")
+	if u.Hostname() == "" {
+		parts = strings.Split(path, ".")
+	}
 	if len(parts) < 2 {
 		err = ErrDomainMissing
 		return
@@ -156,24 +536,37 @@ func normalizeURL(URL string) (s string, err error) {
 		s = fmt.Sprintf("%s://%s", scheme, path)
 	}
 
-	if strings.Count(u.Path, "/") > 1 {
+	if (u.Host != "" && u.Path != "/") || strings.Count(u.Path, "/") > 1 {
 		s += u.Path
 	}
 	return
 }
 
-// NewScanner returns a new scanner that takes a limit as a paramter to limit the number of goroutines spinning up
-func NewScanner(concurrentLimit, depthLimit int, enableLogging bool, keyword string) *Scanner {
-	var searchRegex, contextRegex *regexp.Regexp
-	if strings.Contains(keyword, "(?i)") {
-		searchRegex = regexp.MustCompile(keyword)
-		contextRegex = regexp.MustCompile(fmt.Sprintf("(?i)(<[^<]+)(%s)([^>]+>)", strings.Replace(keyword, "(?i)", "", 1)))
-	} else {
-		searchRegex = regexp.MustCompile("(?i)" + keyword)
-		contextRegex = regexp.MustCompile(fmt.Sprintf("(?i)(<[^<]+)(%s)([^>]+>)", keyword))
+// normalize runs NormalizeFunc when it is set and NormalizeURL otherwise
+func (sc *Scanner) normalize(URL string) (string, error) {
+	if sc.NormalizeFunc != nil {
+		return sc.NormalizeFunc(URL)
 	}
+	return NormalizeURL(URL)
+}
 
-	return &Scanner{
+// SanitizeKeyword trims surrounding whitespace and one pair of matching surrounding quotes from a keyword, which is
+// what usually sneaks in when keywords come from files. It needs to be called before the keyword is handed to the
+// scanner since keywords are compiled into regular expressions as they are
+func SanitizeKeyword(keyword string) string {
+	keyword = strings.TrimSpace(keyword)
+	if len(keyword) >= 2 {
+		first, last := keyword[0], keyword[len(keyword)-1]
+		if first == last && (first == '"' || first == '\'') {
+			keyword = strings.TrimSpace(keyword[1 : len(keyword)-1])
+		}
+	}
+	return keyword
+}
+
+// NewScanner returns a new scanner that takes a limit as a paramter to limit the number of goroutines spinning up
+func NewScanner(concurrentLimit, depthLimit int, enableLogging bool, keyword string) *Scanner {
+	sc := &Scanner{
 		Client: &http.Client{
 			Transport: &http.Transport{
 				Proxy: http.ProxyFromEnvironment,
@@ -186,127 +579,648 @@ func NewScanner(concurrentLimit, depthLimit int, enableLogging bool, keyword str
 			},
 			Timeout: DefaultTimeout,
 		},
-		Keyword:      keyword,
-		DepthLimit:   depthLimit,
-		Semaphore:    make(Semaphore, concurrentLimit),
-		Logging:      enableLogging,
-		contextRegex: contextRegex,
-		searchRegex:  searchRegex,
+		Keyword:              keyword,
+		DepthLimit:           depthLimit,
+		MaxRedirects:         DefaultMaxRedirects,
+		SoftNotFoundPatterns: DefaultSoftNotFoundPatterns,
+		Semaphore:            make(Semaphore, concurrentLimit),
+		Logging:              enableLogging,
+		kw:                   mustKeyword(keyword),
+		hosts:                &hostLimiter{},
+		pacer:                &hostPacer{},
+		robots:               &robotsCache{},
+	}
+	sc.Client.CheckRedirect = sc.checkRedirect
+	return sc
+}
+
+// Share returns a new scanner for keyword that uses sc's client, semaphore and proxies, and shares its per host
+// limits, HostRate and Crawl-delay pacing and robots.txt rules, so those hold across both. Its results and every
+// other setting are its own and start as NewScanner leaves them, the urls DedupURLs has seen included
+func (sc *Scanner) Share(keyword string) *Scanner {
+	s := NewScanner(cap(sc.Semaphore), 0, sc.Logging, keyword)
+	s.Client, s.Semaphore, s.proxies = sc.Client, sc.Semaphore, sc.proxies
+	s.hosts, s.pacer, s.robots = sc.hosts, sc.pacer, sc.robots
+	return s
+}
+
+func (sc *Scanner) saveResult(r Result) {
+	sc.logResult(r)
+	if !sc.DiscardResults {
+		sc.appendResults(r)
 	}
+	sc.notify(r)
 }
 
-func (sc *Scanner) saveResult(URL string, found bool, chunk interface{}) {
+func (sc *Scanner) logResult(r Result) {
 	if sc.Logging {
-		log.Info(logkey, "result", "search term", sc.Keyword, "found", found, "url", URL)
+		log.Info(logkey, "result", "search term", r.Keyword, "found", r.Found, "url", r.URL)
 	}
+}
 
+// appendResults adds to Results under the lock, the first append allocates room for ExpectedResults
+func (sc *Scanner) appendResults(rs ...Result) {
 	sc.mxt.Lock()
-	sc.Results = append(sc.Results, Result{URL: URL, Found: found, Keyword: sc.Keyword, Context: chunk})
+	if sc.Results == nil && sc.ExpectedResults > 0 {
+		sc.Results = make(Results, 0, sc.ExpectedResults)
+	}
+	sc.Results = append(sc.Results, rs...)
 	sc.mxt.Unlock()
+}
+
+// notify hands a saved result to the summary, OnResult and the sinks
+func (sc *Scanner) notify(r Result) {
+	sc.tally.add(r)
+	if sc.OnResult != nil {
+		sc.OnResult(r)
+	}
+	sc.writeSinks(r)
+}
+
+// softNotFound reports whether the body looks like a "page not found" page
+func (sc *Scanner) softNotFound(body []byte) bool {
+	for _, re := range sc.SoftNotFoundPatterns {
+		if re.Match(body) {
+			return true
+		}
+	}
+	return false
+}
+
+// thinContent reports whether the body is too short to be a real page
+func (sc *Scanner) thinContent(body []byte) bool {
+	return int64(len(body)) < sc.MinBodyBytes
+}
+
+// evaluate searches the body for the keyword and builds the Result for the page
+func (sc *Scanner) evaluate(URL string, kw *Keyword, body []byte) Result {
+	thin := sc.thinContent(body)
+	body = sc.UnicodeForm.normalize(body)
+	kw = kw.normalized(sc.UnicodeForm)
+	if sc.SearchPrefixBytes > 0 && int64(len(body)) > sc.SearchPrefixBytes {
+		body = body[:sc.SearchPrefixBytes]
+	}
+
+	r := Result{URL: URL, Keyword: kw.raw, SoftNotFound: sc.softNotFound(body), ThinContent: thin}
+	p := newPage(body)
+	p.unescape = sc.HTMLUnescape
+	if sc.RespectCanonical {
+		if canonical := canonicalURL(p.document(), URL); canonical != "" {
+			r.URL = canonical
+		}
+	}
+
+	switch sc.MatchMode {
+	case MatchText:
+		r.Found = kw.searchRegex.MatchString(p.pageText())
+		if r.Found {
+			r.MatchSource = SourceText
+			r.Context = textContext(p.document(), kw.searchRegex)
+			matches := domMatches(p.document(), kw.searchRegex)
+			r.Matches = &matches
+		}
+		sc.traceMatch(URL, kw, p.pageText(), r.Found)
+	case MatchPhrase:
+		text := p.phraseText(sc.PhraseJoinTags)
+		if loc := kw.searchRegex.FindStringIndex(text); loc != nil {
+			r.Found = true
+			r.MatchSource = SourcePhrase
+			r.Context = phraseContext(text, loc)
+		}
+		sc.traceMatch(URL, kw, text, r.Found)
+	default:
+		var chunk string
+		r.Found, chunk = matchBody(body, kw)
+		r.Context = chunk
+		if r.Found {
+			r.MatchSource = rawSource(p, kw.searchRegex)
+		}
+		if sc.DebugMatch {
+			sc.traceMatch(URL, kw, string(body), r.Found)
+		}
+	}
+
+	if r.Found {
+		text := p.pageText()
+		if sc.MatchMode == MatchPhrase {
+			text = p.phraseText(sc.PhraseJoinTags)
+		}
+		r.Score = keywordDensity(text, len(kw.searchRegex.FindAllStringIndex(text, -1)))
+
+		switch sc.ContextMode {
+		case ContextOuterHTML:
+			if h, ok := outerHTMLContext(p.document(), kw.searchRegex); ok {
+				r.Context = h
+			}
+		case ContextSentence:
+			text := p.phraseText(false)
+			if loc := kw.searchRegex.FindStringIndex(text); loc != nil {
+				r.Context = sentenceContext(text, loc)
+			}
+		}
+		if c, ok := r.Context.(string); ok && !sc.RawContext {
+			r.Context = collapseSpace(c)
+		}
+	}
+	return r
+}
+
+// collapseSpace trims s and turns every run of whitespace in it into a single space
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// searchPage fetches the URL and builds its Result, with StopOnFirstMatch or StreamChunkBytes the body is only read
+// up to the first match
+func (sc *Scanner) searchPage(ctx context.Context, URL string, kw *Keyword) (Result, error) {
+	start := time.Now()
+	if !sc.streaming() {
+		res, body, URL, err := sc.fetchResponse(ctx, URL)
+		if err != nil {
+			return Result{}, err
+		}
+		elapsed := time.Since(start)
+		r := sc.evaluate(URL, kw, body)
+		r.Duration = elapsed
+		sc.describeResponse(&r, res)
+		return r, nil
+	}
+
+	res, URL, err := sc.open(ctx, URL)
+	if err != nil {
+		return Result{}, err
+	}
+	defer res.Body.Close()
+	var r io.Reader = sc.bodyReader(res)
+	if sc.SearchPrefixBytes > 0 {
+		r = io.LimitReader(r, sc.SearchPrefixBytes)
+	}
+	if r, err = sc.transcodeReader(res, r); err != nil {
+		return Result{}, err
+	}
+	var (
+		found bool
+		chunk string
+	)
+	if sc.StreamChunkBytes > 0 && sc.MatchMode == MatchRaw {
+		buf := sc.buffers().get()
+		found, chunk, err = matchChunks(sc.UnicodeForm.reader(r), kw.normalized(sc.UnicodeForm), sc.StreamChunkBytes,
+			sc.StreamOverlapBytes, buf)
+		sc.buffers().put(buf)
+	} else {
+		found, err = matchReader(sc.UnicodeForm.reader(r), kw.normalized(sc.UnicodeForm).searchRegex)
+	}
+	if err != nil {
+		return Result{}, err
+	}
+	result := Result{URL: URL, Keyword: kw.raw, Found: found, Duration: time.Since(start)}
+	if chunk != "" {
+		if !sc.RawContext {
+			chunk = collapseSpace(chunk)
+		}
+		result.Context = chunk
+	}
+	sc.describeResponse(&result, res)
+	if found {
+		result.MatchSource = SourceRaw
+	}
+	return result, nil
+}
+
+// matchReader reads from r only until the first match of re. The regex runs over the stream rune by rune rather
+// than over separate chunks so a match split across reads, like a chunked response, is still found
+func matchReader(r io.Reader, re *regexp.Regexp) (bool, error) {
+	er := &errReader{r: r}
+	found := re.FindReaderIndex(bufio.NewReader(er)) != nil
+	if found {
+		return true, nil
+	}
+	return false, er.err
+}
+
+// errReader keeps the error the regexp package drops when reading a stream, io.EOF isn't an error here
+type errReader struct {
+	r   io.Reader
+	err error
+}
+
+func (e *errReader) Read(p []byte) (int, error) {
+	n, err := e.r.Read(p)
+	if err != nil && err != io.EOF {
+		e.err = err
+	}
+	return n, err
+}
+
+// matchBody reports whether the search regex matches the body and, if so, the surrounding tag as context
+func matchBody(body []byte, kw *Keyword) (found bool, chunk string) {
+	found = kw.searchRegex.Match(body)
+	if found {
+		chunk = newLineReplacer.Replace(string(kw.contextRegex.Find(body)))
+	}
 	return
 }
 
-// Search looks for the passed keyword in the html respose
+// Search looks for the passed keyword in the html respose. To look for several keywords on the same page use
+// SearchMany, which downloads it once for all of them rather than once per keyword
 func (sc *Scanner) Search(URL string) (err error) {
-	sc.Semaphore.load()
-	defer sc.Semaphore.release()
+	return sc.SearchContext(context.Background(), URL)
+}
+
+// SearchContext is Search bound to ctx, canceling it stops waiting on the semaphore and aborts the requests in flight.
+// Pages already searched are kept and the ctx error is returned like any other
+func (sc *Scanner) SearchContext(ctx context.Context, URL string) error {
+	defer sc.markCompleted(1)
+	return sc.search(ctx, URL, sc.kw)
+}
+
+// idKey carries the id passed to SearchWithID down to the results
+type idKey struct{}
+
+// WithID returns a copy of ctx carrying an identifier, such as a row id from the input, that the Search methods set
+// as the ID of every result
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, idKey{}, id)
+}
+
+// SearchWithID is Search with an identifier that is set as the ID of every result
+func (sc *Scanner) SearchWithID(URL, id string) error {
+	return sc.SearchContext(WithID(context.Background(), id), URL)
+}
 
-	URL, err = normalizeURL(URL)
+func (sc *Scanner) search(ctx context.Context, URL string, kw *Keyword) (err error) {
+	defer func() {
+		if err != nil {
+			id, _ := ctx.Value(idKey{}).(string)
+			sc.fail(Result{URL: URL, Keyword: kw.raw, ID: id}, err)
+		}
+	}()
+
+	URL, err = sc.normalize(URL)
 	if err != nil {
 		if sc.Logging {
 			log.Error(logkey, "could not normalize url", "error", err)
 		}
 		return err
 	}
+	if !sc.firstSearch(URL, kw.raw) {
+		return nil
+	}
+	// the pages not saved by the time an error comes back are searched again when they come up
+	claimed := []string{URL}
+	defer func() {
+		if err != nil {
+			sc.forgetSearches(claimed, kw.raw)
+		}
+	}()
+
+	release, err := sc.acquireContext(ctx, URL)
+	if err != nil {
+		return err
+	}
+	defer release()
 
-	urls := linksToCheck(URL, sc.DepthLimit)
-	for _, URL := range urls {
+	if sc.SearchTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, sc.SearchTimeout)
+		defer cancel()
+	}
+
+	seed := URL
+	buf := sc.newResultBuffer()
+	defer buf.flush()
+
+	// with RespectCanonical pages sharing a canonical url are only saved once
+	saved := make(map[string]bool)
+
+	id, _ := ctx.Value(idKey{}).(string)
+	urls, blocked := sc.discover(ctx, URL)
+	urls = append(urls[:1], sc.unsearched(urls[1:], kw.raw)...)
+	claimed = urls
+	for _, b := range blocked {
+		buf.save(Result{URL: b, Keyword: kw.raw, RobotsBlocked: true, SeedURL: seed, ID: id})
+	}
+	for i, p := range sc.searchPages(ctx, urls, kw) {
+		r, err := p.r, p.err
+		if err != nil {
+			claimed = urls[i:]
+			return err
+		}
+		r.ID = id
+		r.SeedURL = seed
+		if sc.RespectCanonical {
+			if saved[r.URL] {
+				continue
+			}
+			saved[r.URL] = true
+		}
+		buf.save(r)
+	}
+
+	return nil
+}
+
+// Match looks for the keyword on a single page and returns the Result directly instead of saving it to sc.Results.
+// Nothing shared is written so it can be used freely from concurrent handlers, the semaphore still bounds outbound requests
+func (sc *Scanner) Match(ctx context.Context, URL, keyword string) (Result, error) {
+	URL, err := sc.normalize(URL)
+	if err != nil {
 		if sc.Logging {
-			log.Info(logkey, "looking for keyword", "keyword", sc.Keyword, "url", URL)
+			log.Error(logkey, "could not normalize url", "error", err)
+		}
+		return Result{}, err
+	}
+
+	release, err := sc.acquireContext(ctx, URL)
+	if err != nil {
+		return Result{}, err
+	}
+	defer release()
+
+	kw, err := NewKeyword(keyword, KeywordOptions{})
+	if err != nil {
+		return Result{}, err
+	}
+	return sc.searchPage(ctx, URL, kw)
+}
+
+// SearchWithRequest sends a prebuilt request and looks for the keyword in the response, use it for pages that are only
+// returned after a POST such as search forms. The request is sent as is, no crawling or https fallback is done
+func (sc *Scanner) SearchWithRequest(req *http.Request, keyword string) (err error) {
+	defer sc.markCompleted(1)
+	defer func() {
+		if err != nil {
+			sc.fail(Result{URL: req.URL.String(), Keyword: keyword}, err)
 		}
+	}()
+
+	release, err := sc.acquireContext(req.Context(), req.URL.String())
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if sc.Logging {
+		log.Info(logkey, "looking for keyword", "keyword", keyword, "method", req.Method, "url", req.URL.String())
+	}
+
+	kw, err := NewKeyword(keyword, KeywordOptions{})
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	res, err := sc.send(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
 
-		body, err := sc.makeRequest(URL)
+	body, err := sc.readBody(res)
+	if err == nil {
+		sc.traceFetch(req.URL.String(), res, body)
+		body, err = sc.transcode(res, body)
+	}
+	if err == nil {
+		body, err = sc.pdfText(res, body)
+	}
+	if err == nil {
+		body, err = sc.transform(body)
+	}
+	if err != nil {
+		return err
+	}
+	elapsed := time.Since(start)
+
+	r := sc.evaluate(req.URL.String(), kw, body)
+	sc.describeResponse(&r, res)
+	r.Duration = elapsed
+	sc.saveResult(r)
+	return nil
+}
+
+// SearchSeeds searches for the keyword starting from several seeds at once. Every seed shares one visited set so pages
+// reachable from more than one seed are only fetched once, the links on a seed are taken from the response it was
+// searched in. Fetches are bounded by the semaphore and the first error is returned. With RespectRobots links
+// robots.txt disallows are saved as RobotsBlocked instead of searched, the seeds never are
+func (sc *Scanner) SearchSeeds(ctx context.Context, seeds []string, keyword string) error {
+	defer sc.markCompleted(len(seeds))
+
+	kw, err := NewKeyword(keyword, KeywordOptions{})
+	if err != nil {
+		return err
+	}
+
+	visited := make(map[string]bool)
+	var roots []string
+	for _, seed := range seeds {
+		URL, err := sc.normalize(seed)
 		if err != nil {
-			if strings.Contains(URL, "https:") {
-				return err
+			if sc.Logging {
+				log.Error(logkey, "could not normalize url", "error", err)
 			}
-			URL = strings.Replace(URL, "http", "https", 1)
-			body, err = sc.makeRequest(URL)
-			if err != nil {
-				return err
+			return err
+		}
+		if !visited[URL] {
+			visited[URL] = true
+			if sc.firstSearch(URL, kw.raw) {
+				roots = append(roots, URL)
 			}
 		}
+	}
 
-		found := sc.searchRegex.Match(body)
-		var context string
-		if found {
-			context = newLineReplacer.Replace(string(sc.contextRegex.Find(body)))
+	var (
+		wg       sync.WaitGroup
+		errMxt   sync.Mutex
+		firstErr error
+	)
+	fail := func(item crawlItem, err error) {
+		sc.fail(Result{URL: item.URL, Keyword: kw.raw, SeedURL: item.root}, err)
+		sc.forgetSearches([]string{item.URL}, kw.raw)
+		errMxt.Lock()
+		if firstErr == nil {
+			firstErr = err
 		}
-		sc.saveResult(URL, found, context)
+		errMxt.Unlock()
 	}
 
-	return nil
+	links := make([][]string, len(roots))
+	blocked := make([][]string, len(roots))
+	for i, root := range roots {
+		wg.Add(1)
+		go func(i int, item crawlItem) {
+			defer wg.Done()
+			release, err := sc.acquireContext(ctx, item.URL)
+			if err != nil {
+				fail(item, err)
+				return
+			}
+			defer release()
+
+			if sc.Logging {
+				log.Info(logkey, "looking for keyword", "keyword", keyword, "url", item.URL)
+			}
+
+			sc.robotsFirst(ctx, item.URL)
+			var r Result
+			if sc.DepthLimit == 0 {
+				r, err = sc.searchPage(ctx, item.URL, kw)
+			} else {
+				// the whole body is needed for the links
+				r, links[i], err = sc.searchSeedPage(ctx, item.URL, kw)
+			}
+			if err != nil {
+				fail(item, err)
+				return
+			}
+			links[i], blocked[i] = sc.robotsAllowed(ctx, links[i])
+			r.SeedURL = item.root
+			sc.saveResult(r)
+		}(i, crawlItem{URL: root, root: root})
+	}
+	wg.Wait()
+
+	var frontier []crawlItem
+	for i, root := range roots {
+		for _, link := range blocked[i] {
+			if !visited[link] {
+				visited[link] = true
+				if sc.firstSearch(link, kw.raw) {
+					sc.saveResult(Result{URL: link, Keyword: kw.raw, RobotsBlocked: true, SeedURL: root})
+				}
+			}
+		}
+		for _, link := range links[i] {
+			if !visited[link] {
+				visited[link] = true
+				if sc.firstSearch(link, kw.raw) {
+					frontier = append(frontier, crawlItem{URL: link, root: root})
+				}
+			}
+		}
+	}
+
+	for _, item := range frontier {
+		wg.Add(1)
+		go func(item crawlItem) {
+			defer wg.Done()
+			release, err := sc.acquireContext(ctx, item.URL)
+			if err != nil {
+				fail(item, err)
+				return
+			}
+			defer release()
+
+			if sc.Logging {
+				log.Info(logkey, "looking for keyword", "keyword", keyword, "url", item.URL)
+			}
+
+			r, err := sc.searchPage(ctx, item.URL, kw)
+			if err != nil {
+				fail(item, err)
+				return
+			}
+			r.SeedURL = item.root
+			sc.saveResult(r)
+		}(item)
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// searchSeedPage searches the page and returns the links on it that would be followed, from the same response
+func (sc *Scanner) searchSeedPage(ctx context.Context, URL string, kw *Keyword) (Result, []string, error) {
+	start := time.Now()
+	res, body, fetched, err := sc.fetchResponse(ctx, URL)
+	if err != nil {
+		return Result{}, nil, err
+	}
+	elapsed := time.Since(start)
+	r := sc.evaluate(fetched, kw, body)
+	r.Duration = elapsed
+	sc.describeResponse(&r, res)
+	return r, sc.extractLinks(body, URL, sc.DepthLimit)[1:], nil
 }
 
 // SearchForEmail returns possible emails from the source pages.  If you do not provide a regex it will use the default value
 // defined in the var EmailRegex, if you wish to filter finds, add a filter slice otherwise everything is can find will be dumped
 func (sc *Scanner) SearchForEmail(URL string, emailRegex *regexp.Regexp, filters []string) (err error) {
+	return sc.SearchForEmailContext(context.Background(), URL, emailRegex, filters)
+}
+
+// SearchForEmailContext is SearchForEmail bound to ctx
+func (sc *Scanner) SearchForEmailContext(ctx context.Context, URL string, emailRegex *regexp.Regexp, filters []string) (err error) {
+	defer sc.markCompleted(1)
+	id, _ := ctx.Value(idKey{}).(string)
+	defer func() {
+		if err != nil {
+			sc.fail(Result{URL: URL, Keyword: sc.Keyword, ID: id}, err)
+		}
+	}()
+
 	if emailRegex == nil {
 		emailRegex = EmailRegex
 	}
 
-	// make sure to use the semaphore we've defined
-	sc.Semaphore.load()
-	defer sc.Semaphore.release()
-
-	URL, err = normalizeURL(URL)
+	URL, err = sc.normalize(URL)
 	if err != nil {
 		if sc.Logging {
 			log.Error(logkey, "could not normalize URL", "error", err)
 		}
 		return err
 	}
+	if !sc.firstSearch(URL, emailRegex.String()) {
+		return nil
+	}
+	claimed := []string{URL}
+	defer func() {
+		if err != nil {
+			sc.forgetSearches(claimed, emailRegex.String())
+		}
+	}()
 
-	urls := linksToCheck(URL, sc.DepthLimit)
-	for _, URL := range urls {
+	// make sure to use the semaphore we've defined
+	release, err := sc.acquireContext(ctx, URL)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	urls, blocked := sc.discover(ctx, URL)
+	urls = append(urls[:1], sc.unsearched(urls[1:], emailRegex.String())...)
+	claimed = urls
+	for _, b := range blocked {
+		sc.saveResult(Result{URL: b, Keyword: sc.Keyword, RobotsBlocked: true, ID: id})
+	}
+	for i, URL := range urls {
 		if sc.Logging {
 			log.Info(logkey, "looking for the a email", "url", URL)
 		}
 
-		body, err := sc.makeRequest(URL)
+		start := time.Now()
+		res, body, URL, err := sc.fetchResponse(ctx, URL)
 		if err != nil {
-			if strings.Contains(URL, "https:") {
-				return err
-			}
-			URL = strings.Replace(URL, "http", "https", 1)
-			body, err = sc.makeRequest(URL)
-			if err != nil {
-				return err
-			}
+			claimed = urls[i:]
+			return err
 		}
+		elapsed := time.Since(start)
 
-		emails := emailRegex.FindStringSubmatch(string(body))
+		// an address containing any of the filters is left out, a page where every address was is not a match
 		var clean []string
-		found := false
-		if len(emails) > 0 {
-			found = true
-
-			for _, e := range emails {
-				if len(filters) > 0 {
-					for _, f := range filters {
-						if !strings.Contains(e, f) && !inSlice(e, clean) && len(e) > 1 {
-							clean = append(clean, e)
-						}
-					}
-				} else {
-					if len(e) > 1 && !inSlice(e, clean) {
-						clean = append(clean, e)
-					}
-				}
-
+		for _, e := range emailRegex.FindAllString(string(body), -1) {
+			if len(e) > 1 && !containsAny(e, filters) && !inSlice(e, clean) {
+				clean = append(clean, e)
 			}
 		}
-		sc.saveResult(URL, found, clean)
+		found := len(clean) > 0
+		r := Result{URL: URL, Found: found, Keyword: sc.Keyword, Context: clean, SoftNotFound: sc.softNotFound(body),
+			ThinContent: sc.thinContent(body), Duration: elapsed}
+		r.ID = id
+		sc.describeResponse(&r, res)
+		if found {
+			r.MatchSource = SourceRaw
+		}
+		sc.saveResult(r)
 	}
 	return
 }
@@ -314,7 +1228,7 @@ func (sc *Scanner) SearchForEmail(URL string, emailRegex *regexp.Regexp, filters
 // ResultsToReader sorts a slice of Result to an io.Reader so that the end user can decide how they want that data
 // csv, text, etc
 func (sc *Scanner) ResultsToReader() (io.Reader, error) {
-	b, err := json.Marshal(sc.Results)
+	b, err := json.Marshal(sc.GetResults())
 	if err != nil {
 		if sc.Logging {
 			log.Error(logkey, "could not marshal data", "error", err)
@@ -324,11 +1238,204 @@ func (sc *Scanner) ResultsToReader() (io.Reader, error) {
 	return bytes.NewReader(b), nil
 }
 
-func (sc *Scanner) makeRequest(URL string) ([]byte, error) {
-	res, err := sc.Client.Get(URL)
+// fetch requests the URL and retries over https when the plain http request fails, the URL actually fetched is returned
+func (sc *Scanner) fetch(ctx context.Context, URL string) ([]byte, string, error) {
+	_, body, URL, err := sc.fetchResponse(ctx, URL)
+	return body, URL, err
+}
+
+// fetchResponse is fetch that also returns the response the body came from, its body is already closed
+func (sc *Scanner) fetchResponse(ctx context.Context, URL string) (*http.Response, []byte, string, error) {
+	res, body, URL, err := sc.get(ctx, URL)
+	if err != nil {
+		return nil, nil, URL, err
+	}
+	if sc.FollowMetaRefresh {
+		if res, body, URL, err = sc.followMetaRefresh(ctx, res, body, URL); err != nil {
+			return nil, nil, URL, err
+		}
+	}
+	sc.traceFetch(URL, res, body)
+	if body, err = sc.transcode(res, body); err != nil {
+		return nil, nil, URL, err
+	}
+	if body, err = sc.pdfText(res, body); err != nil {
+		return nil, nil, URL, err
+	}
+	body, err = sc.transform(body)
+	return res, body, URL, err
+}
+
+// get is open with the body read and closed, the headers of the returned response can still be used
+func (sc *Scanner) get(ctx context.Context, URL string) (*http.Response, []byte, string, error) {
+	if sc.Fetcher != nil {
+		return sc.fetchWith(ctx, URL)
+	}
+
+	res, URL, err := sc.open(ctx, URL)
+	if err != nil {
+		return nil, nil, URL, err
+	}
+	defer res.Body.Close()
+
+	body, err := sc.readBody(res)
+	return res, body, URL, err
+}
+
+// open is fetch without reading the body, the caller must close it
+func (sc *Scanner) open(ctx context.Context, URL string) (*http.Response, string, error) {
+	res, err := sc.do(ctx, URL)
+	if err != nil {
+		// a redirect loop will be the same over https so don't bother retrying
+		var loop *ErrRedirectLoop
+		if strings.Contains(URL, "https:") || errors.As(err, &loop) {
+			return nil, URL, err
+		}
+		URL = strings.Replace(URL, "http", "https", 1)
+		res, err = sc.do(ctx, URL)
+		if err != nil {
+			return nil, URL, err
+		}
+	}
+	return res, URL, nil
+}
+
+func (sc *Scanner) makeRequest(ctx context.Context, URL string) ([]byte, error) {
+	if sc.Fetcher != nil {
+		_, body, _, err := sc.fetchWith(ctx, URL)
+		return body, err
+	}
+
+	res, err := sc.do(ctx, URL)
 	if err != nil {
 		return []byte(""), err
 	}
 	defer res.Body.Close()
-	return ioutil.ReadAll(res.Body)
+	return sc.readBody(res)
+}
+
+func (sc *Scanner) do(ctx context.Context, URL string) (*http.Response, error) {
+	return sc.doMethod(ctx, http.MethodGet, URL)
+}
+
+// doMethod sends a request without a body, retrying DNS failures and, up to Retries times, timeouts and 5xx responses
+func (sc *Scanner) doMethod(ctx context.Context, method, URL string) (*http.Response, error) {
+	req, err := http.NewRequest(method, URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+
+	res, err := sc.sendResolving(ctx, req)
+	for attempt := 0; attempt < sc.Retries && ctx.Err() == nil && isTransient(res, err); attempt++ {
+		if res != nil {
+			res.Body.Close()
+		}
+		if !sleep(ctx, sc.retryBackoff(attempt)) {
+			return nil, ctx.Err()
+		}
+		if sc.Logging {
+			log.Warn(logkey, "retrying request", "url", URL, "attempt", attempt+1, "status", statusOf(res), "error", err)
+		}
+		res, err = sc.sendResolving(ctx, req)
+	}
+	return res, err
+}
+
+// sendResolving is send retrying DNS failures up to DNSRetries times
+func (sc *Scanner) sendResolving(ctx context.Context, req *http.Request) (*http.Response, error) {
+	res, err := sc.send(req)
+	for attempt := 0; err != nil && attempt < sc.DNSRetries && isDNSError(err); attempt++ {
+		if !sleep(ctx, sc.dnsBackoff(attempt)) {
+			return nil, err
+		}
+		if sc.Logging {
+			log.Warn(logkey, "retrying dns failure", "url", req.URL.String(), "attempt", attempt+1, "error", err)
+		}
+		res, err = sc.send(req)
+	}
+	return res, err
+}
+
+// send is the single place requests leave the scanner, every prebuilt or generated request goes through it. Bodies
+// come back decompressed
+func (sc *Scanner) send(req *http.Request) (*http.Response, error) {
+	req = sc.withHeaders(req)
+	if err := sc.pace(req); err != nil {
+		return nil, err
+	}
+	atomic.AddInt64(&sc.requests, 1)
+	res, err := sc.viaProxy(req)
+	if err == nil {
+		decodeBody(res)
+	}
+	return res, err
+}
+
+// bodyReader returns the response body capped at MaxBodyBytes
+func (sc *Scanner) bodyReader(res *http.Response) io.Reader {
+	if sc.MaxBodyBytes > 0 {
+		res.Body = &cappedBody{ReadCloser: res.Body, left: sc.MaxBodyBytes}
+	}
+	return res.Body
+}
+
+// cappedBody is a response body that reads as ending after MaxBodyBytes, it notes whether anything was cut off so
+// describeResponse can tell
+type cappedBody struct {
+	io.ReadCloser
+	left      int64
+	checked   bool
+	truncated bool
+}
+
+func (b *cappedBody) Read(p []byte) (int, error) {
+	if b.left <= 0 {
+		// one more byte says whether the body went on
+		if !b.checked {
+			b.checked = true
+			n, _ := io.ReadFull(b.ReadCloser, make([]byte, 1))
+			b.truncated = n > 0
+		}
+		return 0, io.EOF
+	}
+	if int64(len(p)) > b.left {
+		p = p[:b.left]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.left -= int64(n)
+	return n, err
+}
+
+// readBody reads the body capped at MaxBodyBytes, the response's ContentLength is set to the bytes read so it is
+// right for compressed or chunked responses too. The body is read into a pooled buffer, which does all the growing,
+// and copied out in one allocation of its exact size. The buffer itself isn't handed on because the body outlives
+// this call, it is matched, has its links pulled out and is shared by SearchMany's goroutines, so there is no single
+// point where the buffer could safely go back to the pool
+func (sc *Scanner) readBody(res *http.Response) ([]byte, error) {
+	buf := sc.buffers().get()
+	defer sc.buffers().put(buf)
+	_, err := buf.ReadFrom(sc.bodyReader(res))
+	body := make([]byte, buf.Len())
+	copy(body, buf.Bytes())
+	res.ContentLength = int64(len(body))
+	return body, err
+}
+
+// describeResponse records the response's status, length, truncation and redirects on the result
+func (sc *Scanner) describeResponse(r *Result, res *http.Response) {
+	r.StatusCode = res.StatusCode
+	r.ContentLength = res.ContentLength
+	if b, ok := res.Body.(*cappedBody); ok {
+		r.Truncated = b.truncated
+	}
+	r.RedirectChain = sc.redirectChain(res)
+}
+
+// transform runs BodyTransform over a fetched body when one is set
+func (sc *Scanner) transform(body []byte) ([]byte, error) {
+	if sc.BodyTransform == nil {
+		return body, nil
+	}
+	return sc.BodyTransform(body)
 }
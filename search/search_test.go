@@ -1,8 +1,20 @@
 package search
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
 	"testing"
+	"testing/iotest"
+	"time"
 )
 
 func TestSortInterface(t *testing.T) {
@@ -31,6 +43,70 @@ func TestSortInterface(t *testing.T) {
 	}
 }
 
+func TestMatchingURLs(t *testing.T) {
+	r := Results{
+		Result{URL: "a.com", Found: true},
+		Result{URL: "b.com"},
+		Result{URL: "c.com", Found: true},
+	}
+
+	urls := r.MatchingURLs()
+	if len(urls) != 2 || urls[0] != "a.com" || urls[1] != "c.com" {
+		t.Errorf("expected [a.com c.com] got %v", urls)
+	}
+
+	if urls := (Results{Result{URL: "b.com"}}).MatchingURLs(); len(urls) != 0 {
+		t.Errorf("expected no urls got %v", urls)
+	}
+}
+
+func TestGroupByKeyword(t *testing.T) {
+	re := regexp.MustCompile(`sign\s+up`)
+	r := Results{
+		Result{URL: "a.com", Keyword: "login", Found: true},
+		Result{URL: "a.com", Keyword: re},
+		Result{URL: "b.com", Keyword: "login"},
+		Result{URL: "b.com", Keyword: re, Found: true},
+		Result{URL: "c.com", Keyword: "login"},
+	}
+
+	groups := r.GroupByKeyword()
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 groups got %d", len(groups))
+	}
+
+	login := groups["login"]
+	if len(login) != 3 || login[0].URL != "a.com" || login[2].URL != "c.com" {
+		t.Errorf("unexpected login group %+v", login)
+	}
+
+	signUp := groups[re.String()]
+	if len(signUp) != 2 || !signUp[1].Found {
+		t.Errorf("unexpected regex group %+v", signUp)
+	}
+}
+
+func TestSanitizeKeyword(t *testing.T) {
+	var cases = []struct {
+		In  string
+		Out string
+	}{
+		{"  sign up  ", "sign up"},
+		{` "sign up" `, "sign up"},
+		{`'sign up'`, "sign up"},
+		{`" sign up "`, "sign up"},
+		{`"sign up'`, `"sign up'`},
+		{`say "hi"`, `say "hi"`},
+		{`"`, `"`},
+	}
+
+	for _, c := range cases {
+		if out := SanitizeKeyword(c.In); out != c.Out {
+			t.Errorf("SanitizeKeyword(%q) expected %q got %q", c.In, c.Out, out)
+		}
+	}
+}
+
 func TestNormalizeURL(t *testing.T) {
 	var cases = []struct {
 		Name string
@@ -44,12 +120,16 @@ func TestNormalizeURL(t *testing.T) {
 		{"no domain", "http://facebook", ErrDomainMissing.Error()},
 		{"no domain or protocol", "facebook", ErrDomainMissing.Error()},
 		{"long path", "https://en.wikipedia.org/wiki/Email_address", "https://en.wikipedia.org/wiki/Email_address"},
+		{"single segment path", "https://facebook.com/about", "https://facebook.com/about"},
+		{"single segment path with a trailing slash", "https://facebook.com/about/", "https://facebook.com/about/"},
+		{"single segment path with a port", "http://127.0.0.1:8080/a", "http://127.0.0.1:8080/a"},
+		{"port", "http://facebook.com:8080/", "http://facebook.com:8080"},
 		{"bad url formating", "%2i23jr93udn.com", "parse %2i23jr93udn.com: invalid URL escape \"%2i\""},
 	}
 
 	for i, c := range cases {
 		t.Run(c.Name, func(t *testing.T) {
-			out, err := normalizeURL(c.In)
+			out, err := NormalizeURL(c.In)
 			if err == nil {
 				if c.Out != out {
 					t.Fatalf("test %d failed. expected %s got %s", i, c.Out, out)
@@ -63,6 +143,39 @@ func TestNormalizeURL(t *testing.T) {
 	}
 }
 
+func TestNormalizeFunc(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/en/" {
+			fmt.Fprint(w, "<html><body><p>sign up</p></body></html>")
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer ts.Close()
+
+	sc := NewScanner(1, 0, false, "sign up")
+	sc.NormalizeFunc = func(URL string) (string, error) {
+		URL, err := NormalizeURL(URL)
+		if err != nil {
+			return "", err
+		}
+		return URL + "/en/", nil
+	}
+	if err := sc.Search(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	if len(sc.Results) != 1 || sc.Results[0].URL != ts.URL+"/en/" || !sc.Results[0].Found {
+		t.Errorf("expected the custom normalized url to be searched, got %+v", sc.Results)
+	}
+
+	sc.NormalizeFunc = func(URL string) (string, error) {
+		return "", ErrDomainMissing
+	}
+	if err := sc.Search(ts.URL); err != ErrDomainMissing {
+		t.Errorf("expected the normalizer's error, got %v", err)
+	}
+}
+
 func TestScanner(t *testing.T) {
 	sc := NewScanner(1, 0, false, "Connect with friends")
 	err := sc.Search("facebook.com/")
@@ -111,4 +224,596 @@ func TestGetResults(t *testing.T) {
 	if len(results) != len(sc.Results) {
 		t.Errorf("length of results should be equal to length sc.GetResults()")
 	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				sc.saveResult(Result{URL: fmt.Sprintf("http://example.com/%d/%d", i, j)})
+				sc.GetResults()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	snapshot := sc.GetResults()
+	if len(snapshot) != 200 {
+		t.Fatalf("expected 200 results, got %d", len(snapshot))
+	}
+	snapshot[0].URL = "changed"
+	if sc.GetResults()[0].URL == "changed" {
+		t.Error("changing the snapshot shouldn't change the scanner's results")
+	}
+}
+
+func TestMatch(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><a href="/signup">Sign Up</a></body></html>`)
+	}))
+	defer ts.Close()
+
+	sc := NewScanner(1, 0, false, "")
+	res, err := sc.Match(context.Background(), ts.URL, "sign up")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !res.Found {
+		t.Errorf("keyword should have been found on the page")
+	}
+
+	if res.Keyword != "sign up" {
+		t.Errorf("expected keyword sign up got %v", res.Keyword)
+	}
+
+	if len(sc.Results) != 0 {
+		t.Errorf("Match should not save results, found %d", len(sc.Results))
+	}
+}
+
+func TestLinksToCheckPatterns(t *testing.T) {
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<html><body>
+			<a href="%[1]s/docs/intro">intro</a>
+			<a href="%[1]s/blog/news">news</a>
+			<a href="%[1]s/docs/api">api</a>
+			<a href="%[1]s/docs/old/api">old api</a>
+		</body></html>`, ts.URL)
+	}))
+	defer ts.Close()
+
+	sc := NewScanner(1, 10, false, "")
+	sc.IncludePattern = regexp.MustCompile(`/docs/`)
+	sc.ExcludePattern = regexp.MustCompile(`/old/`)
+
+	links := sc.linksToCheck(context.Background(), ts.URL, sc.DepthLimit)
+	want := []string{ts.URL, ts.URL + "/docs/intro", ts.URL + "/docs/api"}
+	if len(links) != len(want) {
+		t.Fatalf("expected %v got %v", want, links)
+	}
+	for i := range want {
+		if links[i] != want[i] {
+			t.Errorf("link %d expected %s got %s", i, want[i], links[i])
+		}
+	}
+}
+
+func TestSearchSeeds(t *testing.T) {
+	var (
+		ts   *httptest.Server
+		mu   sync.Mutex
+		hits = make(map[string]int)
+	)
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits[r.URL.Path]++
+		mu.Unlock()
+
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprintf(w, `<html><body><a href="%[1]s/a/shared">shared</a><a href="%[1]s/other">other</a></body></html>`, ts.URL)
+		case "/a":
+			fmt.Fprintf(w, `<html><body><a href="%s/a/shared">shared</a></body></html>`, ts.URL)
+		default:
+			fmt.Fprint(w, `<html><body><p>common keyword</p></body></html>`)
+		}
+	}))
+	defer ts.Close()
+
+	sc := NewScanner(2, 10, false, "")
+	err := sc.SearchSeeds(context.Background(), []string{ts.URL, ts.URL + "/a"}, "common keyword")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hits["/a/shared"] != 1 {
+		t.Errorf("the shared page should have been fetched once, fetched %d times", hits["/a/shared"])
+	}
+	for _, seed := range []string{"/", "/a"} {
+		if hits[seed] != 1 {
+			t.Errorf("the seed %s should have been fetched once for both its links and the keyword, fetched %d times",
+				seed, hits[seed])
+		}
+	}
+
+	// root, /a, /a/shared and /other
+	if len(sc.Results) != 4 {
+		t.Errorf("expected 4 results got %d", len(sc.Results))
+	}
+}
+
+func TestLinksToCheckLimits(t *testing.T) {
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<html><body>")
+		for i := 0; i < 5000; i++ {
+			fmt.Fprintf(w, `<a href="%s/page/%d">page</a>`, ts.URL, i)
+		}
+		fmt.Fprint(w, "</body></html>")
+	}))
+	defer ts.Close()
+
+	sc := NewScanner(1, 10000, false, "")
+	sc.MaxLinksPerPage = 100
+	links := sc.linksToCheck(context.Background(), ts.URL, sc.DepthLimit)
+	if len(links) != sc.MaxLinksPerPage+1 {
+		t.Errorf("expected the base url plus %d links got %d", sc.MaxLinksPerPage, len(links))
+	}
+
+	sc = NewScanner(1, 10000, false, "")
+	sc.MaxBodyBytes = 1024
+	links = sc.linksToCheck(context.Background(), ts.URL, sc.DepthLimit)
+	if len(links) <= 1 || len(links) > 30 {
+		t.Errorf("only the links within the first %d bytes should be found, got %d", sc.MaxBodyBytes, len(links))
+	}
+}
+
+func TestSoftNotFound(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			fmt.Fprint(w, `<html><head><title>Oops</title></head><body><h1>Page Not Found</h1><p>sign up</p></body></html>`)
+			return
+		}
+		fmt.Fprint(w, `<html><body><p>sign up</p></body></html>`)
+	}))
+	defer ts.Close()
+
+	sc := NewScanner(1, 0, false, "")
+	res, err := sc.Match(context.Background(), ts.URL+"/missing", "sign up")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.SoftNotFound {
+		t.Errorf("a 200 page saying page not found should be flagged")
+	}
+
+	res, err = sc.Match(context.Background(), ts.URL, "sign up")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.SoftNotFound {
+		t.Errorf("a normal page should not be flagged")
+	}
+
+	sc.SoftNotFoundPatterns = nil
+	res, err = sc.Match(context.Background(), ts.URL+"/missing", "sign up")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.SoftNotFound {
+		t.Errorf("the check should be off without patterns")
+	}
+}
+
+func TestThinContent(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/empty" {
+			fmt.Fprint(w, "ok\n")
+			return
+		}
+		fmt.Fprint(w, `<html><body><p>sign up</p></body></html>`)
+	}))
+	defer ts.Close()
+
+	sc := NewScanner(1, 0, false, "")
+	res, err := sc.Match(context.Background(), ts.URL+"/empty", "sign up")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ThinContent {
+		t.Errorf("nothing should be thin without MinBodyBytes")
+	}
+
+	sc.MinBodyBytes = 16
+	if res, err = sc.Match(context.Background(), ts.URL+"/empty", "sign up"); err != nil {
+		t.Fatal(err)
+	}
+	if !res.ThinContent {
+		t.Errorf("a 3 byte body should be flagged")
+	}
+
+	if res, err = sc.Match(context.Background(), ts.URL, "sign up"); err != nil {
+		t.Fatal(err)
+	}
+	if res.ThinContent {
+		t.Errorf("a normal page should not be flagged")
+	}
+}
+
+type countingReader struct {
+	r    io.Reader
+	read int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.read += n
+	return n, err
+}
+
+func TestStopOnFirstMatch(t *testing.T) {
+	page := "<html><body><p>sign up</p>" + strings.Repeat("<p>filler</p>", 100000) + "</body></html>"
+	cr := &countingReader{r: strings.NewReader(page)}
+
+	if found, err := matchReader(cr, regexp.MustCompile("(?i)sign up")); !found || err != nil {
+		t.Fatalf("keyword should have been found, %v", err)
+	}
+
+	if cr.read >= len(page) {
+		t.Errorf("reading should have stopped early, read %d of %d bytes", cr.read, len(page))
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, page)
+	}))
+	defer ts.Close()
+
+	sc := NewScanner(1, 0, false, "")
+	sc.StopOnFirstMatch = true
+	res, err := sc.Match(context.Background(), ts.URL, "sign up")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Found {
+		t.Errorf("keyword should have been found")
+	}
+}
+
+func TestStopOnFirstMatchChunked(t *testing.T) {
+	done := make(chan struct{})
+	defer close(done)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the keyword is split over two chunks and the stream is left open after it
+		for _, chunk := range []string{"<html><body><p>si", "gn u", "p</p>"} {
+			fmt.Fprint(w, chunk)
+			w.(http.Flusher).Flush()
+		}
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+	defer ts.Close()
+
+	sc := NewScanner(1, 0, false, "")
+	sc.Client.Timeout = 5 * time.Second
+	sc.StopOnFirstMatch = true
+	start := time.Now()
+	res, err := sc.Match(context.Background(), ts.URL, "sign up")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Found {
+		t.Errorf("a keyword split across chunks should have been found")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("reading should have stopped at the match, took %v", elapsed)
+	}
+}
+
+func TestMatchReaderError(t *testing.T) {
+	r := io.MultiReader(strings.NewReader("<p>sign"), iotest.ErrReader(errors.New("connection reset")))
+	found, err := matchReader(r, regexp.MustCompile("(?i)sign up"))
+	if found || err == nil {
+		t.Errorf("expected the read error when the stream breaks before a match, got %v %v", found, err)
+	}
+}
+
+func TestSearchTimeout(t *testing.T) {
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		fmt.Fprintf(w, `<html><body><a href="%s/slow">slow</a><p>sign up</p></body></html>`, ts.URL)
+	}))
+	defer ts.Close()
+
+	sc := NewScanner(1, 2, false, "sign up")
+	sc.SearchTimeout = 100 * time.Millisecond
+	if err := sc.Search(ts.URL); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the seed to time out on its slow link, got %v", err)
+	}
+
+	sc = NewScanner(1, 2, false, "sign up")
+	sc.SearchTimeout = time.Second
+	if err := sc.Search(ts.URL); err != nil {
+		t.Errorf("expected the seed to finish within the timeout, got %v", err)
+	}
+}
+
+func TestSearchContext(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><p>sign up</p></body></html>`)
+	}))
+	defer ts.Close()
+
+	sc := NewScanner(1, 0, false, "sign up")
+	if err := sc.SearchContext(WithID(context.Background(), "row-1"), ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	if len(sc.Results) != 1 || sc.Results[0].ID != "row-1" || !sc.Results[0].Found {
+		t.Fatalf("expected one found result with the id from ctx, got %+v", sc.Results)
+	}
+
+	// with the only slot taken a canceled search gives up instead of waiting for it
+	sc = NewScanner(1, 0, false, "sign up")
+	sc.SaveErrors = true
+	release := sc.acquire(ts.URL)
+	defer release()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := sc.SearchContext(ctx, ts.URL); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if err := sc.SearchForEmailContext(ctx, ts.URL, nil, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled for emails, got %v", err)
+	}
+	if len(sc.Results) != 2 || sc.Results[0].Error == "" {
+		t.Errorf("expected both urls saved as failed, got %+v", sc.Results)
+	}
+}
+
+func TestContextWaitingForSlot(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><p>sign up</p></body></html>`)
+	}))
+	defer ts.Close()
+
+	// the only slot is held so every call has to give up on ctx rather than wait for it
+	sc := NewScanner(1, 0, false, "sign up")
+	release := sc.acquire(ts.URL)
+	defer release()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := map[string]func() error{
+		"Crawl": func() error { return sc.Crawl(ctx, []string{ts.URL}, "sign up", 1) },
+		"SearchSeeds": func() error {
+			return sc.SearchSeeds(ctx, []string{ts.URL}, "sign up")
+		},
+		"Match": func() error {
+			_, err := sc.Match(ctx, ts.URL, "sign up")
+			return err
+		},
+		"MatchWithHeaders": func() error {
+			_, err := sc.MatchWithHeaders(ctx, ts.URL, "sign up", nil)
+			return err
+		},
+		"SearchPaginated": func() error {
+			return sc.SearchPaginated(ctx, ts.URL, "sign up", func([]byte) (string, error) { return "", nil }, 1)
+		},
+		"CrawlFeedContext": func() error { return sc.CrawlFeedContext(ctx, ts.URL, "sign up") },
+		"SearchWithRequest": func() error {
+			req, err := http.NewRequest(http.MethodGet, ts.URL, nil)
+			if err != nil {
+				return err
+			}
+			return sc.SearchWithRequest(req.WithContext(ctx), "sign up")
+		},
+	}
+	for name, call := range calls {
+		done := make(chan error, 1)
+		go func() { done <- call() }()
+		select {
+		case err := <-done:
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("%s: expected context.Canceled, got %v", name, err)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("%s kept waiting for a slot after ctx was canceled", name)
+		}
+	}
+}
+
+func TestResponseDetails(t *testing.T) {
+	const page = `<html><body><p>sign up</p></body></html>`
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/gone" {
+			w.WriteHeader(http.StatusGone)
+		}
+		fmt.Fprint(w, page)
+	}))
+	defer ts.Close()
+
+	for _, stream := range []bool{false, true} {
+		sc := NewScanner(1, 0, false, "sign up")
+		sc.StopOnFirstMatch = stream
+		if err := sc.Search(ts.URL + "/gone"); err != nil {
+			t.Fatal(err)
+		}
+		r := sc.Results[0]
+		if r.StatusCode != http.StatusGone || r.ContentLength != int64(len(page)) || r.Duration <= 0 {
+			t.Errorf("stream %v: expected status 410, length %d and a duration, got %+v", stream, len(page), r)
+		}
+	}
+}
+
+func TestMaxBodyBytes(t *testing.T) {
+	const page = `<html><body><p>welcome</p><p>sign up</p></body></html>`
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, page)
+	}))
+	defer ts.Close()
+
+	for _, stream := range []bool{false, true} {
+		for limit, truncated := range map[int64]bool{24: true, int64(len(page)): false} {
+			sc := NewScanner(1, 0, false, "sign up")
+			sc.StopOnFirstMatch = stream
+			sc.MaxBodyBytes = limit
+			if err := sc.Search(ts.URL); err != nil {
+				t.Fatal(err)
+			}
+			r := sc.Results[0]
+			if r.Truncated != truncated || r.Found == truncated {
+				t.Errorf("stream %v, limit %d: expected truncated %v and found %v, got %+v", stream, limit, truncated, !truncated, r)
+			}
+			if !stream && r.ContentLength > limit {
+				t.Errorf("stream %v, limit %d: read %d bytes", stream, limit, r.ContentLength)
+			}
+		}
+	}
+}
+
+func TestSearchWithRequest(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.FormValue("q") == "gophers" {
+			fmt.Fprint(w, `<html><body><p>results for gophers</p></body></html>`)
+			return
+		}
+		fmt.Fprint(w, `<html><body><form method="post"></form></body></html>`)
+	}))
+	defer ts.Close()
+
+	sc := NewScanner(1, 0, false, "")
+	req, err := http.NewRequest(http.MethodPost, ts.URL, strings.NewReader("q=gophers"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	if err := sc.SearchWithRequest(req, "results for gophers"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sc.Results) != 1 || !sc.Results[0].Found {
+		t.Errorf("the keyword should have been found in the POST response, got %+v", sc.Results)
+	}
+
+	res, err := sc.Match(context.Background(), ts.URL, "results for gophers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Found {
+		t.Errorf("a GET should not return the keyword")
+	}
+}
+
+func TestSearchPrefixBytes(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<html><body><p>welcome</p>"+strings.Repeat("<p>filler</p>", 1000)+"<p>sign up</p></body></html>")
+	}))
+	defer ts.Close()
+
+	sc := NewScanner(1, 0, false, "")
+	sc.SearchPrefixBytes = 1024
+	for _, stop := range []bool{false, true} {
+		sc.StopOnFirstMatch = stop
+
+		res, err := sc.Match(context.Background(), ts.URL, "sign up")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Found {
+			t.Errorf("the keyword past the prefix should not be found, StopOnFirstMatch %v", stop)
+		}
+
+		res, err = sc.Match(context.Background(), ts.URL, "welcome")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !res.Found {
+			t.Errorf("the keyword inside the prefix should be found, StopOnFirstMatch %v", stop)
+		}
+	}
+}
+
+func TestDuration(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(5 * time.Millisecond)
+		fmt.Fprint(w, "<html><body><p>sign up</p></body></html>")
+	}))
+	defer ts.Close()
+
+	sc := NewScanner(1, 0, false, "sign up")
+	for _, stop := range []bool{false, true} {
+		sc.StopOnFirstMatch = stop
+		res, err := sc.Match(context.Background(), ts.URL, "sign up")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Duration < 5*time.Millisecond {
+			t.Errorf("expected the duration to cover the fetch, got %v, StopOnFirstMatch %v", res.Duration, stop)
+		}
+	}
+
+	if err := sc.Search(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	if sc.Results[0].Duration == 0 {
+		t.Error("expected a saved result to have a duration")
+	}
+}
+
+func TestBodyTransform(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<html><body><p>sign up</p></body></html>")
+	}))
+	defer ts.Close()
+
+	sc := NewScanner(1, 0, false, "")
+	sc.BodyTransform = func(b []byte) ([]byte, error) {
+		return bytes.ToUpper(b), nil
+	}
+	kw, err := NewKeyword("SIGN UP", KeywordOptions{CaseSensitive: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sc.SearchCompiled(ts.URL, kw); err != nil {
+		t.Fatal(err)
+	}
+	if len(sc.Results) != 1 || !sc.Results[0].Found || sc.Results[0].Context != "<P>SIGN UP</P>" {
+		t.Errorf("expected matching to see the transformed body, got %+v", sc.Results)
+	}
+
+	errTransform := errors.New("transform failed")
+	sc.BodyTransform = func(b []byte) ([]byte, error) {
+		return nil, errTransform
+	}
+	sc.StopOnFirstMatch = true
+	if _, err := sc.Match(context.Background(), ts.URL, "sign up"); err != errTransform {
+		t.Errorf("expected the transform error to be returned, got %v", err)
+	}
+}
+
+func TestByURL(t *testing.T) {
+	results := Results{
+		{URL: "http://a.com", Keyword: "sign up", Found: true},
+		{URL: "http://b.com", Keyword: "sign up"},
+		{URL: "http://a.com", Keyword: "log in"},
+	}
+
+	m := results.ByURL()
+	if len(m) != 2 {
+		t.Fatalf("expected 2 urls got %d", len(m))
+	}
+	if r := m["http://a.com"]; r.Keyword != "log in" || r.Found {
+		t.Errorf("expected the last result for a duplicate url to win, got %+v", r)
+	}
+	if _, ok := m["http://c.com"]; ok {
+		t.Error("expected no entry for a url that wasn't searched")
+	}
 }
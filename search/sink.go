@@ -0,0 +1,221 @@
+package search
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	log "github.com/marcsantiago/logger"
+)
+
+// Sink receives every saved result, Write may be called from many goroutines at once
+type Sink interface {
+	Write(Result) error
+	Close() error
+}
+
+// ResultStore is a sink that keeps the results it is sent, such as a database, so a long scan persists them as it
+// goes and they can be read back once the program has exited. Register it with AddSink
+type ResultStore interface {
+	Sink
+	// Load returns every stored result in the order they were written
+	Load() (Results, error)
+}
+
+// AddSink registers a sink that will receive every result saved from then on. Use DiscardResults to only send
+// results to the sinks
+func (sc *Scanner) AddSink(s Sink) {
+	sc.sinkMxt.Lock()
+	sc.sinks = append(sc.sinks, s)
+	sc.sinkMxt.Unlock()
+}
+
+// CloseSinks closes every registered sink, the first error is returned but every sink is closed
+func (sc *Scanner) CloseSinks() (err error) {
+	sc.sinkMxt.Lock()
+	defer sc.sinkMxt.Unlock()
+	for _, s := range sc.sinks {
+		if cerr := s.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	sc.sinks = nil
+	return
+}
+
+func (sc *Scanner) writeSinks(r Result) {
+	sc.sinkMxt.Lock()
+	sinks := sc.sinks
+	sc.sinkMxt.Unlock()
+
+	for _, s := range sinks {
+		if err := s.Write(r); err != nil && sc.Logging {
+			log.Error(logkey, "sink could not write result", "url", r.URL, "error", err)
+		}
+	}
+}
+
+// DefaultContextSeparator joins the values of a list context, such as the emails found by SearchForEmail, in text
+// output
+const DefaultContextSeparator = "; "
+
+// ContextString formats a result context for text output. A list, such as the emails found by SearchForEmail, is
+// joined with sep rather than printed in brackets and a missing context is empty rather than <nil>
+func ContextString(v interface{}, sep string) string {
+	switch c := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return c
+	case []string:
+		return strings.Join(c, sep)
+	case []interface{}:
+		// a list context read back from json
+		parts := make([]string, len(c))
+		for i, p := range c {
+			parts[i] = fmt.Sprintf("%v", p)
+		}
+		return strings.Join(parts, sep)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// contextString is ContextString with DefaultContextSeparator
+func contextString(v interface{}) string {
+	return ContextString(v, DefaultContextSeparator)
+}
+
+// CSVSink writes results as url,found,context,duration rows, the header is written with the first row
+type CSVSink struct {
+	// Separator joins the values of a list context, DefaultContextSeparator unless changed before the first Write
+	Separator string
+
+	mxt    sync.Mutex
+	w      io.Writer
+	csv    *csv.Writer
+	header bool
+}
+
+// NewCSVSink returns a sink writing csv to w, if w is an io.Closer it is closed by Close
+func NewCSVSink(w io.Writer) *CSVSink {
+	return &CSVSink{Separator: DefaultContextSeparator, w: w, csv: csv.NewWriter(w)}
+}
+
+// Write writes the result as a csv row
+func (s *CSVSink) Write(r Result) error {
+	s.mxt.Lock()
+	defer s.mxt.Unlock()
+
+	if !s.header {
+		if err := s.csv.Write([]string{"url", "found", "context", "duration"}); err != nil {
+			return err
+		}
+		s.header = true
+	}
+
+	row := []string{r.URL, strconv.FormatBool(r.Found), ContextString(r.Context, s.Separator), r.Duration.String()}
+	if err := s.csv.Write(row); err != nil {
+		return err
+	}
+	s.csv.Flush()
+	return s.csv.Error()
+}
+
+// Close flushes the csv and closes the underlying writer when it can be
+func (s *CSVSink) Close() error {
+	s.mxt.Lock()
+	defer s.mxt.Unlock()
+
+	s.csv.Flush()
+	if err := s.csv.Error(); err != nil {
+		return err
+	}
+	if c, ok := s.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// JSONLSink writes every result as one json object per line
+type JSONLSink struct {
+	mxt sync.Mutex
+	w   io.Writer
+	enc *json.Encoder
+}
+
+// NewJSONLSink returns a sink writing json lines to w, if w is an io.Closer it is closed by Close
+func NewJSONLSink(w io.Writer) *JSONLSink {
+	return &JSONLSink{w: w, enc: json.NewEncoder(w)}
+}
+
+// Write encodes the result on its own line
+func (s *JSONLSink) Write(r Result) error {
+	s.mxt.Lock()
+	defer s.mxt.Unlock()
+	return s.enc.Encode(r)
+}
+
+// Close closes the underlying writer when it can be
+func (s *JSONLSink) Close() error {
+	s.mxt.Lock()
+	defer s.mxt.Unlock()
+	if c, ok := s.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// ChanSink hands every result to a channel so it can be written out as soon as it is produced, rather than held in
+// Results. Write blocks until the result is received, so a slow reader slows the scan down instead of piling results
+// up in memory
+type ChanSink struct {
+	mxt    sync.RWMutex
+	c      chan Result
+	closed bool
+}
+
+// NewChanSink returns a sink with a channel buffering up to size results
+func NewChanSink(size int) *ChanSink {
+	return &ChanSink{c: make(chan Result, size)}
+}
+
+// Results is the channel results are sent on, it is closed by Close
+func (s *ChanSink) Results() <-chan Result {
+	return s.c
+}
+
+// Write sends the result on the channel
+func (s *ChanSink) Write(r Result) error {
+	s.mxt.RLock()
+	defer s.mxt.RUnlock()
+	if s.closed {
+		return ErrSinkClosed
+	}
+	s.c <- r
+	return nil
+}
+
+// Close closes the channel once the writes in flight are received
+func (s *ChanSink) Close() error {
+	s.mxt.Lock()
+	defer s.mxt.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.c)
+	}
+	return nil
+}
+
+// ResultsChan switches the scanner to streaming, results are no longer kept in Results and are instead sent on the
+// returned channel as soon as they are saved. The channel must be read until it is closed by CloseSinks, which should
+// be called once every search has returned
+func (sc *Scanner) ResultsChan(size int) <-chan Result {
+	s := NewChanSink(size)
+	sc.DiscardResults = true
+	sc.AddSink(s)
+	return s.Results()
+}
@@ -0,0 +1,176 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type fakeSink struct {
+	mxt     sync.Mutex
+	results Results
+	closed  bool
+}
+
+func (f *fakeSink) Write(r Result) error {
+	f.mxt.Lock()
+	defer f.mxt.Unlock()
+	f.results = append(f.results, r)
+	return nil
+}
+
+func (f *fakeSink) Close() error {
+	f.closed = true
+	return nil
+}
+
+func TestSinks(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><p>sign up</p></body></html>`)
+	}))
+	defer ts.Close()
+
+	sc := NewScanner(2, 0, false, "sign up")
+	sc.DiscardResults = true
+	sink := &fakeSink{}
+	sc.AddSink(sink)
+
+	if err := sc.SearchSeeds(context.Background(), []string{ts.URL, ts.URL + "/other"}, "sign up"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sc.CloseSinks(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sink.results) != 2 {
+		t.Errorf("the sink should have received 2 results got %d", len(sink.results))
+	}
+
+	if !sink.closed {
+		t.Errorf("the sink should have been closed")
+	}
+
+	if len(sc.Results) != 0 {
+		t.Errorf("results should have been discarded, found %d", len(sc.Results))
+	}
+}
+
+func TestCSVSink(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewCSVSink(&buf)
+	s.Write(Result{URL: "a.com", Found: true, Context: "has, a comma"})
+	s.Write(Result{URL: "b.com"})
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "url,found,context,duration\na.com,true,\"has, a comma\",0s\nb.com,false,,0s\n"
+	if buf.String() != want {
+		t.Errorf("expected %q got %q", want, buf.String())
+	}
+}
+
+func TestJSONLSink(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewJSONLSink(&buf)
+	s.Write(Result{URL: "a.com", Found: true})
+	s.Write(Result{URL: "b.com"})
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines got %d", len(lines))
+	}
+
+	var r Result
+	if err := json.Unmarshal([]byte(lines[0]), &r); err != nil {
+		t.Fatal(err)
+	}
+	if r.URL != "a.com" || !r.Found {
+		t.Errorf("unexpected first result %+v", r)
+	}
+}
+
+func TestListContext(t *testing.T) {
+	emails := []string{"sales@example.com", "help@example.com"}
+	results := Results{{URL: "http://example.com", Found: true, Context: emails}}
+
+	var buf bytes.Buffer
+	if err := writeResults(&buf, results, FormatCSV, DefaultContextSeparator); err != nil {
+		t.Fatal(err)
+	}
+	if want := "http://example.com,true,sales@example.com; help@example.com,0s\n"; !strings.HasSuffix(buf.String(), want) {
+		t.Errorf("expected the emails joined, got %q", buf.String())
+	}
+
+	buf.Reset()
+	if err := writeResults(&buf, results, FormatCSV, "|"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), ",sales@example.com|help@example.com,") {
+		t.Errorf("expected the emails joined with the separator, got %q", buf.String())
+	}
+
+	buf.Reset()
+	if err := writeResults(&buf, results, FormatJSONL, DefaultContextSeparator); err != nil {
+		t.Fatal(err)
+	}
+	var decoded Result
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if got := ContextString(decoded.Context, ","); got != "sales@example.com,help@example.com" {
+		t.Errorf("expected the emails kept as a json array, got %s", buf.String())
+	}
+
+	if got := ContextString(nil, ","); got != "" {
+		t.Errorf("expected a missing context to be empty, got %q", got)
+	}
+}
+
+func TestResultsChan(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><p>sign up</p></body></html>`)
+	}))
+	defer ts.Close()
+
+	sc := NewScanner(2, 0, false, "sign up")
+	results := sc.ResultsChan(0)
+
+	urls := []string{ts.URL + "/a", ts.URL + "/b", ts.URL + "/c"}
+	go func() {
+		if err := sc.SearchBatch(context.Background(), urls); err != nil {
+			t.Error(err)
+		}
+		sc.CloseSinks()
+	}()
+
+	var n int
+	for r := range results {
+		if !r.Found {
+			t.Errorf("expected %s to be found", r.URL)
+		}
+		n++
+	}
+	if n != len(urls) {
+		t.Errorf("expected %d results on the channel, got %d", len(urls), n)
+	}
+	if len(sc.Results) != 0 {
+		t.Errorf("results should not be kept, found %d", len(sc.Results))
+	}
+
+	s := NewChanSink(1)
+	s.Close()
+	if err := s.Write(Result{}); err != ErrSinkClosed {
+		t.Errorf("expected ErrSinkClosed after Close, got %v", err)
+	}
+}
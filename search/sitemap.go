@@ -0,0 +1,170 @@
+package search
+
+import (
+	"context"
+	"encoding/xml"
+	"net/url"
+	"strings"
+	"sync"
+
+	log "github.com/marcsantiago/logger"
+)
+
+// maxSitemapDepth is how deep sitemap indexes are followed, an index of indexes of sitemaps is as deep as it goes
+const maxSitemapDepth = 3
+
+// sitemap holds both a urlset and a sitemapindex, whichever the document is the other stays empty
+type sitemap struct {
+	URLs []struct {
+		Loc string `xml:"loc"`
+	} `xml:"url"`
+	Sitemaps []struct {
+		Loc string `xml:"loc"`
+	} `xml:"sitemap"`
+}
+
+// parseSitemap reads the page urls and nested sitemap urls out of a sitemap, gzipped sitemaps are decompressed first
+func parseSitemap(body []byte) (pages, sitemaps []string, err error) {
+	if body, err = gunzip(body); err != nil {
+		return nil, nil, err
+	}
+
+	var sm sitemap
+	if err := xml.Unmarshal(body, &sm); err != nil {
+		return nil, nil, err
+	}
+	for _, u := range sm.URLs {
+		if loc := strings.TrimSpace(u.Loc); loc != "" {
+			pages = append(pages, loc)
+		}
+	}
+	for _, s := range sm.Sitemaps {
+		if loc := strings.TrimSpace(s.Loc); loc != "" {
+			sitemaps = append(sitemaps, loc)
+		}
+	}
+	return
+}
+
+// sitemapURL is the sitemap to start from, the URL itself when it points at an xml file and otherwise /sitemap.xml
+// on its host
+func sitemapURL(URL string) (string, error) {
+	u, err := url.Parse(URL)
+	if err != nil {
+		return "", err
+	}
+	if strings.HasSuffix(u.Path, ".xml") || strings.HasSuffix(u.Path, ".xml.gz") {
+		return URL, nil
+	}
+	return u.Scheme + "://" + u.Host + "/sitemap.xml", nil
+}
+
+// sitemapPages fetches the sitemap and returns every page it lists, following sitemap indexes. Only the first
+// sitemap failing is an error, a nested one that fails is logged and skipped
+func (sc *Scanner) sitemapPages(ctx context.Context, sitemapURL string, depth int, seen map[string]bool) ([]string, error) {
+	seen[sitemapURL] = true
+
+	release, err := sc.acquireContext(ctx, sitemapURL)
+	if err != nil {
+		return nil, err
+	}
+	body, _, err := sc.fetch(ctx, sitemapURL)
+	release()
+	if err != nil {
+		return nil, err
+	}
+
+	pages, nested, err := parseSitemap(body)
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range nested {
+		if seen[n] || depth >= maxSitemapDepth {
+			continue
+		}
+		more, err := sc.sitemapPages(ctx, n, depth+1, seen)
+		if err != nil {
+			if sc.Logging {
+				log.Error(logkey, "could not read nested sitemap", "url", n, "error", err)
+			}
+			continue
+		}
+		pages = append(pages, more...)
+	}
+	return pages, nil
+}
+
+// CrawlSitemap searches for the keyword on every page listed in a site's sitemap, the URL's /sitemap.xml or the URL
+// itself when it points at an xml file. Sitemap indexes are followed and sitemaps may be gzipped. Each page is
+// searched like Search, following links up to DepthLimit, pages listed twice are searched once and the first error is
+// returned. With RespectRobots pages robots.txt disallows are saved with RobotsBlocked set. Pages are searched by as
+// many workers as the semaphore has slots and the whole sitemap counts as one url towards Completed
+func (sc *Scanner) CrawlSitemap(ctx context.Context, siteURL, keyword string) error {
+	defer sc.markCompleted(1)
+
+	kw, err := NewKeyword(keyword, KeywordOptions{})
+	if err != nil {
+		return err
+	}
+
+	siteURL, err = sc.normalize(siteURL)
+	if err != nil {
+		return err
+	}
+	start, err := sitemapURL(siteURL)
+	if err != nil {
+		return err
+	}
+
+	id, _ := ctx.Value(idKey{}).(string)
+	pages, err := sc.sitemapPages(ctx, start, 1, make(map[string]bool))
+	if err != nil {
+		sc.fail(Result{URL: start, Keyword: kw.raw, ID: id}, err)
+		if sc.Logging {
+			log.Error(logkey, "could not read sitemap", "url", start, "error", err)
+		}
+		return err
+	}
+
+	unique := pages[:0]
+	listed := make(map[string]bool, len(pages))
+	for _, p := range pages {
+		if !listed[p] {
+			listed[p] = true
+			unique = append(unique, p)
+		}
+	}
+	pages, blocked := sc.robotsAllowed(ctx, unique)
+	for _, b := range blocked {
+		sc.saveResult(Result{URL: b, Keyword: kw.raw, RobotsBlocked: true, SeedURL: siteURL, ID: id})
+	}
+
+	// an index can list tens of thousands of pages, a fixed set of workers keeps the goroutines to the semaphore's size
+	workers := cap(sc.Semaphore)
+	if workers < 1 {
+		workers = 1
+	}
+	next := make(chan string)
+	errs := make(chan error, len(pages))
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for page := range next {
+				if err := sc.search(ctx, page, kw); err != nil {
+					errs <- err
+				}
+			}
+		}()
+	}
+	for _, page := range pages {
+		next <- page
+	}
+	close(next)
+	wg.Wait()
+	close(errs)
+
+	// nil when the channel is empty
+	return <-errs
+}
@@ -0,0 +1,67 @@
+package search
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+)
+
+func TestCrawlSitemap(t *testing.T) {
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sitemap.xml":
+			fmt.Fprintf(w, `<?xml version="1.0"?><sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+				<sitemap><loc>%[1]s/pages.xml</loc></sitemap>
+				<sitemap><loc> %[1]s/more.xml.gz </loc></sitemap>
+				<sitemap><loc>%[1]s/sitemap.xml</loc></sitemap>
+			</sitemapindex>`, ts.URL)
+		case "/pages.xml":
+			fmt.Fprintf(w, `<?xml version="1.0"?><urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+				<url><loc>%[1]s/a</loc></url><url><loc>%[1]s/b</loc></url>
+			</urlset>`, ts.URL)
+		case "/more.xml.gz":
+			var buf bytes.Buffer
+			zw := gzip.NewWriter(&buf)
+			fmt.Fprintf(zw, `<urlset><url><loc>%[1]s/c</loc></url><url><loc>%[1]s/a</loc></url></urlset>`, ts.URL)
+			zw.Close()
+			w.Write(buf.Bytes())
+		case "/a", "/c":
+			fmt.Fprint(w, "<html><body><p>sign up</p></body></html>")
+		default:
+			fmt.Fprint(w, "<html><body><p>nothing here</p></body></html>")
+		}
+	}))
+	defer ts.Close()
+
+	for _, start := range []string{ts.URL, ts.URL + "/sitemap.xml"} {
+		sc := NewScanner(2, 0, false, "")
+		if err := sc.CrawlSitemap(context.Background(), start, "sign up"); err != nil {
+			t.Fatalf("%s: %v", start, err)
+		}
+		if len(sc.Results) != 3 {
+			t.Errorf("%s: expected a result for each of the 3 pages, got %+v", start, sc.Results)
+		}
+		found := sc.Results.MatchingURLs()
+		sort.Strings(found)
+		if want := fmt.Sprint([]string{ts.URL + "/a", ts.URL + "/c"}); fmt.Sprint(found) != want {
+			t.Errorf("%s: expected %v to match, got %v", start, want, found)
+		}
+		if sc.Completed() != 1 {
+			t.Errorf("%s: expected the sitemap to count as one url, got %d", start, sc.Completed())
+		}
+	}
+
+	sc := NewScanner(2, 0, false, "")
+	if err := sc.CrawlSitemap(context.Background(), ts.URL+"/missing.xml", "sign up"); err != nil {
+		t.Fatal(err)
+	}
+	if len(sc.Results) != 0 {
+		t.Errorf("expected nothing to search from a page that isn't a sitemap, got %+v", sc.Results)
+	}
+}
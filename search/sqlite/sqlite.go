@@ -0,0 +1,103 @@
+// Package sqlite is a search.ResultStore backed by a SQLite file. It uses cgo, import it only where a store is needed
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/marcsantiago/search_keyword/search"
+	// registers the sqlite3 driver
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// schema keeps the fields worth filtering on as columns, result holds the whole Result as json so Load gives back
+// everything that was written
+const schema = `CREATE TABLE IF NOT EXISTS results (
+	id             INTEGER PRIMARY KEY AUTOINCREMENT,
+	url            TEXT NOT NULL,
+	keyword        TEXT NOT NULL,
+	found          INTEGER NOT NULL,
+	status_code    INTEGER NOT NULL,
+	content_length INTEGER NOT NULL,
+	duration_ms    INTEGER NOT NULL,
+	input_id       TEXT NOT NULL,
+	seed_url       TEXT NOT NULL,
+	error          TEXT NOT NULL,
+	saved_at       TIMESTAMP NOT NULL,
+	result         TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS results_url ON results (url);`
+
+// Store writes every result to the results table as soon as it is saved
+type Store struct {
+	db *sql.DB
+}
+
+// Open opens or creates the SQLite file at path and its results table, results already in the file are kept
+func Open(path string) (*Store, error) {
+	db, err := sql.Open("sqlite3", path+"?_busy_timeout=5000")
+	if err != nil {
+		return nil, err
+	}
+	// sqlite allows a single writer, one connection saves the results from racing for the lock
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// DB is the underlying database for queries of your own, e.g.
+// SELECT url FROM results WHERE found AND status_code = 200
+func (s *Store) DB() *sql.DB {
+	return s.db
+}
+
+// Write inserts the result
+func (s *Store) Write(r search.Result) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	var keyword string
+	if r.Keyword != nil {
+		keyword = fmt.Sprint(r.Keyword)
+	}
+	_, err = s.db.Exec(`INSERT INTO results
+		(url, keyword, found, status_code, content_length, duration_ms, input_id, seed_url, error, saved_at, result)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.URL, keyword, r.Found, r.StatusCode, r.ContentLength, r.Duration.Milliseconds(), r.ID, r.SeedURL, r.Error,
+		time.Now().UTC(), string(b))
+	return err
+}
+
+// Load returns every result in the table in the order they were written
+func (s *Store) Load() (search.Results, error) {
+	rows, err := s.db.Query(`SELECT result FROM results ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results search.Results
+	for rows.Next() {
+		var b []byte
+		if err := rows.Scan(&b); err != nil {
+			return nil, err
+		}
+		var r search.Result
+		if err := json.Unmarshal(b, &r); err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
+
+// Close closes the database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,108 @@
+package sqlite
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/marcsantiago/search_keyword/search"
+)
+
+var _ search.ResultStore = (*Store)(nil)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.db")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				r := search.Result{URL: fmt.Sprintf("http://a.com/%d/%d", i, j), Keyword: "sign up", Found: j%2 == 0,
+					StatusCode: 200, Duration: time.Second}
+				if err := s.Write(r); err != nil {
+					t.Error(err)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	err = s.Write(search.Result{URL: "http://b.com", Keyword: "email", Found: true,
+		Context: []string{"a@b.com", "c@d.com"}, ID: "row-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the results outlive the store
+	s, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	results, err := s.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 41 {
+		t.Fatalf("expected 41 results, got %d", len(results))
+	}
+	last := results[40]
+	if last.URL != "http://b.com" || last.ID != "row-1" || fmt.Sprint(last.Context) != "[a@b.com c@d.com]" {
+		t.Errorf("expected the last result back as written, got %+v", last)
+	}
+
+	var found int
+	if err := s.DB().QueryRow(`SELECT count(*) FROM results WHERE found AND duration_ms = 1000`).Scan(&found); err != nil {
+		t.Fatal(err)
+	}
+	if found != 20 {
+		t.Errorf("expected 20 found rows, got %d", found)
+	}
+}
+
+func TestStoreAsSink(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><p>sign up</p></body></html>`)
+	}))
+	defer ts.Close()
+
+	path := filepath.Join(t.TempDir(), "results.db")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc := search.NewScanner(1, 0, false, "sign up")
+	sc.DiscardResults = true
+	sc.AddSink(s)
+	if err := sc.Search(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	if err := sc.CloseSinks(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	results, err := s.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || !results[0].Found || results[0].StatusCode != http.StatusOK {
+		t.Errorf("expected the search result in the store, got %+v", results)
+	}
+}
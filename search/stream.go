@@ -0,0 +1,48 @@
+package search
+
+import (
+	"bytes"
+	"io"
+)
+
+// DefaultStreamOverlap is how much of each chunk is carried over to the next when StreamOverlapBytes isn't set
+const DefaultStreamOverlap = 4096
+
+// streaming reports whether searchPage can match the body as it is read rather than reading all of it first. A
+// transform or pdf needs the whole body, a Fetcher always hands over the whole body and text matching needs the
+// parsed page
+func (sc *Scanner) streaming() bool {
+	if sc.BodyTransform != nil || sc.PDFExtractor != nil || sc.Fetcher != nil {
+		return false
+	}
+	return sc.StopOnFirstMatch || (sc.StreamChunkBytes > 0 && sc.MatchMode == MatchRaw)
+}
+
+// matchChunks reads r chunk bytes at a time and matches kw against each chunk joined to the end of the one before,
+// so at most chunk plus overlap bytes are held whatever the size of the page. Reading stops at the first match and
+// the context is taken from the window it was found in. A match longer than overlap that straddles two chunks is
+// missed. The window is held in buf, which is grown as needed
+func matchChunks(r io.Reader, kw *Keyword, chunk, overlap int, buf *bytes.Buffer) (bool, string, error) {
+	if overlap <= 0 {
+		overlap = DefaultStreamOverlap
+	}
+	buf.Reset()
+	buf.Grow(overlap + chunk)
+	window := buf.Bytes()[: 0 : overlap+chunk]
+	for {
+		n, err := io.ReadFull(r, window[len(window):len(window)+chunk])
+		window = window[:len(window)+n]
+		if n > 0 && kw.searchRegex.Match(window) {
+			return true, newLineReplacer.Replace(string(kw.contextRegex.Find(window))), nil
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return false, "", nil
+		}
+		if err != nil {
+			return false, "", err
+		}
+		if len(window) > overlap {
+			window = append(window[:0], window[len(window)-overlap:]...)
+		}
+	}
+}
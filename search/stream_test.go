@@ -0,0 +1,61 @@
+package search
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMatchChunks(t *testing.T) {
+	kw, err := NewKeyword("sign up", KeywordOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := strings.Repeat("<p>filler</p>", 10) + `<a href="/join">sign up</a>` + strings.Repeat("<p>filler</p>", 10)
+
+	for _, tt := range []struct {
+		chunk, overlap int
+		found          bool
+	}{
+		{16, 64, true},
+		{7, 16, true},
+		// the match straddles two chunks and is longer than the overlap
+		{150, 2, false},
+		{len(page), 1, true},
+	} {
+		found, chunk, err := matchChunks(strings.NewReader(page), kw, tt.chunk, tt.overlap, new(bytes.Buffer))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found != tt.found {
+			t.Errorf("chunk %d, overlap %d: expected found %v", tt.chunk, tt.overlap, tt.found)
+		}
+		if found && tt.overlap >= 64 && chunk != `<a href="/join">sign up</a>` {
+			t.Errorf("chunk %d, overlap %d: expected the surrounding tag as context, got %q", tt.chunk, tt.overlap, chunk)
+		}
+	}
+}
+
+func TestStreamChunkBytes(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < 1000; i++ {
+			fmt.Fprint(w, "<p>nothing to see here</p>\n")
+		}
+		fmt.Fprint(w, `<a href="/join">Sign
+		up</a>`)
+	}))
+	defer ts.Close()
+
+	sc := NewScanner(1, 0, false, `sign\s+up`)
+	sc.StreamChunkBytes = 512
+	if err := sc.Search(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	r := sc.Results[0]
+	if !r.Found || r.Context != `<a href="/join">Sign up</a>` || r.MatchSource != SourceRaw {
+		t.Errorf("expected the keyword found with its context, got %+v", r)
+	}
+}
@@ -0,0 +1,111 @@
+package search
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// topKeywords is how many keywords Summary lists
+const topKeywords = 5
+
+// Summary is an overview of everything the scanner has saved so far
+type Summary struct {
+	// URLs is the number of results saved
+	URLs int `json:"urls"`
+	// Matched is the number of results where the keyword was found
+	Matched int `json:"matched"`
+	// Errored is the number of urls that failed before a result could be saved
+	Errored int `json:"errored"`
+	// Domains is the number of distinct hosts across the results
+	Domains int `json:"unique_domains"`
+	// TopKeywords are the keywords found on the most pages, most found first
+	TopKeywords []KeywordCount `json:"top_keywords,omitempty"`
+}
+
+// KeywordCount is how many pages a keyword was found on
+type KeywordCount struct {
+	Keyword string `json:"keyword"`
+	Found   int    `json:"found"`
+}
+
+// String formats the summary for the terminal
+func (s Summary) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "urls: %d, matched: %d, errored: %d, unique domains: %d", s.URLs, s.Matched, s.Errored, s.Domains)
+	if len(s.TopKeywords) > 0 {
+		kws := make([]string, len(s.TopKeywords))
+		for i, k := range s.TopKeywords {
+			kws[i] = fmt.Sprintf("%s (%d)", k.Keyword, k.Found)
+		}
+		fmt.Fprintf(&b, "\ntop keywords: %s", strings.Join(kws, ", "))
+	}
+	return b.String()
+}
+
+// tally keeps the running counts behind Summary, it is updated as results are saved so it works with DiscardResults
+type tally struct {
+	mxt      sync.Mutex
+	urls     int
+	matched  int
+	domains  map[string]bool
+	keywords map[string]int
+}
+
+func (t *tally) add(r Result) {
+	t.mxt.Lock()
+	defer t.mxt.Unlock()
+
+	if t.domains == nil {
+		t.domains = make(map[string]bool)
+		t.keywords = make(map[string]int)
+	}
+	t.urls++
+	if u, err := url.Parse(r.URL); err == nil && u.Host != "" {
+		t.domains[u.Host] = true
+	}
+	if r.Found {
+		t.matched++
+		t.keywords[keywordString(r.Keyword)]++
+	}
+}
+
+// fail counts a failed url and, with SaveErrors, saves r with the error
+func (sc *Scanner) fail(r Result, err error) {
+	atomic.AddInt64(&sc.errored, 1)
+	if sc.SaveErrors {
+		r.Error = err.Error()
+		sc.saveResult(r)
+	}
+}
+
+// Summary returns the totals of everything saved so far, it is safe to call while searches are running
+func (sc *Scanner) Summary() Summary {
+	t := &sc.tally
+	t.mxt.Lock()
+	defer t.mxt.Unlock()
+
+	s := Summary{
+		URLs:    t.urls,
+		Matched: t.matched,
+		Errored: int(atomic.LoadInt64(&sc.errored)),
+		Domains: len(t.domains),
+	}
+	for k, n := range t.keywords {
+		s.TopKeywords = append(s.TopKeywords, KeywordCount{Keyword: k, Found: n})
+	}
+	sort.Slice(s.TopKeywords, func(i, j int) bool {
+		a, b := s.TopKeywords[i], s.TopKeywords[j]
+		if a.Found != b.Found {
+			return a.Found > b.Found
+		}
+		return a.Keyword < b.Keyword
+	})
+	if len(s.TopKeywords) > topKeywords {
+		s.TopKeywords = s.TopKeywords[:topKeywords]
+	}
+	return s
+}
@@ -0,0 +1,56 @@
+package search
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestSummary(t *testing.T) {
+	sc := NewScanner(1, 0, false, "")
+	sc.DiscardResults = true
+	for _, r := range []Result{
+		{URL: "http://a.com", Keyword: "sign up", Found: true},
+		{URL: "http://a.com/about", Keyword: "sign up", Found: true},
+		{URL: "http://b.com", Keyword: "sign up"},
+		{URL: "http://b.com", Keyword: "login", Found: true},
+		{URL: "http://c.com:8080/x", Keyword: "pricing", Found: true},
+		{URL: "http://c.com:8080/y", Keyword: "pricing", Found: true},
+	} {
+		sc.saveResult(r)
+	}
+	sc.fail(Result{URL: "http://d.com"}, errors.New("timed out"))
+
+	s := sc.Summary()
+	want := Summary{
+		URLs:    6,
+		Matched: 5,
+		Errored: 1,
+		Domains: 3,
+		TopKeywords: []KeywordCount{
+			{Keyword: "pricing", Found: 2},
+			{Keyword: "sign up", Found: 2},
+			{Keyword: "login", Found: 1},
+		},
+	}
+	got, _ := json.Marshal(s)
+	exp, _ := json.Marshal(want)
+	if string(got) != string(exp) {
+		t.Errorf("expected %s, got %s", exp, got)
+	}
+
+	str := "urls: 6, matched: 5, errored: 1, unique domains: 3\ntop keywords: pricing (2), sign up (2), login (1)"
+	if s.String() != str {
+		t.Errorf("expected %q, got %q", str, s.String())
+	}
+}
+
+func TestSummaryCountsErrors(t *testing.T) {
+	sc := NewScanner(1, 0, false, "sign up")
+	if err := sc.Search(""); err == nil {
+		t.Fatal("expected an error for an empty url")
+	}
+	if s := sc.Summary(); s.Errored != 1 || s.URLs != 0 {
+		t.Errorf("expected one error and no urls, got %+v", s)
+	}
+}
@@ -0,0 +1,145 @@
+package search
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// MatchMode decides what the keyword is matched against
+type MatchMode int
+
+const (
+	// MatchRaw is the default, the keyword is matched against the raw html so tags and attributes can match
+	MatchRaw MatchMode = iota
+	// MatchText matches against the visible text of the page only, the DOM path of every match is recorded
+	MatchText
+	// MatchPhrase matches against the visible text with every run of whitespace collapsed to one space and tag
+	// boundaries read as spaces, so a phrase matches however the markup breaks it up. See PhraseJoinTags
+	MatchPhrase
+)
+
+// phraseContextChars is how much text either side of a phrase match is kept as context
+const phraseContextChars = 40
+
+// page lazily parses a fetched body so the html is only parsed once however many features need it
+type page struct {
+	body     []byte
+	doc      *goquery.Document
+	text     string
+	haveText bool
+	// unescape decodes entities left in the text once the html has been parsed
+	unescape   bool
+	phrase     string
+	havePhrase bool
+}
+
+func newPage(body []byte) *page {
+	return &page{body: body}
+}
+
+// unescapeText decodes html entities in every text node under n
+func unescapeText(n *html.Node) {
+	if n.Type == html.TextNode {
+		n.Data = html.UnescapeString(n.Data)
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		unescapeText(c)
+	}
+}
+
+// document returns the parsed page, script and style elements are dropped so they never count as text
+func (p *page) document() *goquery.Document {
+	if p.doc != nil {
+		return p.doc
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(p.body))
+	if err != nil {
+		doc, _ = goquery.NewDocumentFromReader(strings.NewReader(""))
+	}
+	doc.Find("script, style, noscript").Remove()
+	if p.unescape {
+		for _, n := range doc.Nodes {
+			unescapeText(n)
+		}
+	}
+	p.doc = doc
+	return doc
+}
+
+// pageText returns the visible text of the page
+func (p *page) pageText() string {
+	if !p.haveText {
+		p.text = p.document().Text()
+		p.haveText = true
+	}
+	return p.text
+}
+
+// phraseText returns the visible text with whitespace collapsed, tag boundaries are read as spaces unless joinTags
+func (p *page) phraseText(joinTags bool) string {
+	if p.havePhrase {
+		return p.phrase
+	}
+
+	text := p.pageText()
+	if !joinTags {
+		var b strings.Builder
+		for _, n := range p.document().Nodes {
+			writeText(&b, n)
+		}
+		text = b.String()
+	}
+	p.phrase = collapseSpace(text)
+	p.havePhrase = true
+	return p.phrase
+}
+
+// writeText writes every text node under n followed by a space
+func writeText(b *strings.Builder, n *html.Node) {
+	if n.Type == html.TextNode {
+		b.WriteString(n.Data)
+		b.WriteByte(' ')
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		writeText(b, c)
+	}
+}
+
+// phraseContext is the match at loc with up to phraseContextChars bytes of text either side, cut back to whole runes
+func phraseContext(text string, loc []int) string {
+	start, end := loc[0]-phraseContextChars, loc[1]+phraseContextChars
+	if start < 0 {
+		start = 0
+	}
+	if end > len(text) {
+		end = len(text)
+	}
+	for start < loc[0] && !utf8.RuneStart(text[start]) {
+		start++
+	}
+	for end < len(text) && end > loc[1] && !utf8.RuneStart(text[end]) {
+		end--
+	}
+	return strings.TrimSpace(text[start:end])
+}
+
+// textContext is the text of the innermost element holding the match with new lines removed
+func textContext(doc *goquery.Document, re *regexp.Regexp) string {
+	sel := matchingElement(doc, re)
+	return strings.TrimSpace(newLineReplacer.Replace(sel.Text()))
+}
+
+// keywordDensity is the number of keyword matches per 1000 words of the page text
+func keywordDensity(text string, matches int) float64 {
+	words := len(strings.Fields(text))
+	if words == 0 {
+		return 0
+	}
+	return float64(matches) * 1000 / float64(words)
+}
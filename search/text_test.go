@@ -0,0 +1,137 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestScore(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		filler := strings.Repeat("lorem ipsum dolor sit amet ", 20)
+		switch r.URL.Path {
+		case "/many":
+			fmt.Fprintf(w, "<html><body><p>golang %s golang golang</p></body></html>", filler)
+		case "/none":
+			fmt.Fprintf(w, "<html><body><p>%s</p></body></html>", filler)
+		default:
+			fmt.Fprintf(w, "<html><body><p>golang %s</p><script>golang golang</script></body></html>", filler)
+		}
+	}))
+	defer ts.Close()
+
+	sc := NewScanner(1, 0, false, "")
+	many, err := sc.Match(context.Background(), ts.URL+"/many", "golang")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	one, err := sc.Match(context.Background(), ts.URL+"/one", "golang")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	none, err := sc.Match(context.Background(), ts.URL+"/none", "golang")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if many.Score <= one.Score {
+		t.Errorf("the page with more matches should score higher, got %f and %f", many.Score, one.Score)
+	}
+
+	if one.Score <= 0 {
+		t.Errorf("a page with a match should have a score above 0, got %f", one.Score)
+	}
+
+	if none.Score != 0 {
+		t.Errorf("a page without a match should score 0, got %f", none.Score)
+	}
+}
+
+func TestKeywordDensity(t *testing.T) {
+	if d := keywordDensity("", 3); d != 0 {
+		t.Errorf("empty text should have no density, got %f", d)
+	}
+
+	if d := keywordDensity("one two three four", 2); d != 500 {
+		t.Errorf("expected 500 got %f", d)
+	}
+}
+
+func TestHTMLUnescape(t *testing.T) {
+	kw := mustKeyword("AT&T")
+	tests := []struct {
+		body     string
+		mode     MatchMode
+		unescape bool
+		found    bool
+	}{
+		// the parser decodes entities once on its own in text mode
+		{"<p>AT&amp;T wireless</p>", MatchText, false, true},
+		// escaped twice only matches once unescaped
+		{"<p>AT&amp;amp;T wireless</p>", MatchText, false, false},
+		{"<p>AT&amp;amp;T wireless</p>", MatchText, true, true},
+		// raw matching is never unescaped
+		{"<p>AT&amp;T wireless</p>", MatchRaw, true, false},
+	}
+	for _, test := range tests {
+		sc := NewScanner(1, 0, false, "")
+		sc.MatchMode = test.mode
+		sc.HTMLUnescape = test.unescape
+		r := sc.evaluate("http://example.com", kw, []byte("<html><body>"+test.body+"</body></html>"))
+		if r.Found != test.found {
+			t.Errorf("%s mode %v unescape %v: expected found %v", test.body, test.mode, test.unescape, test.found)
+		}
+		if r.Found && r.Context != "AT&T wireless" {
+			t.Errorf("%s: expected the unescaped text as context, got %v", test.body, r.Context)
+		}
+	}
+}
+
+func TestMatchPhrase(t *testing.T) {
+	kw := mustKeyword("Connect with friends")
+	tests := []struct {
+		body     string
+		joinTags bool
+		found    bool
+		context  string
+	}{
+		{"<p>Connect\n\t  with</p><p><b>friends</b> today</p>", false, true, "Connect with friends today"},
+		// without tag boundaries the paragraphs run together
+		{"<p>Connect\n\t  with</p><p><b>friends</b> today</p>", true, false, ""},
+		{"<p>Con<b>nect</b> with friends</p>", false, false, ""},
+		{"<p>Con<b>nect</b> with friends</p>", true, true, "Connect with friends"},
+	}
+	for _, test := range tests {
+		sc := NewScanner(1, 0, false, "")
+		sc.MatchMode = MatchPhrase
+		sc.PhraseJoinTags = test.joinTags
+		r := sc.evaluate("http://example.com", kw, []byte("<html><body>"+test.body+"</body></html>"))
+		if r.Found != test.found {
+			t.Errorf("%q join tags %v: expected found %v", test.body, test.joinTags, test.found)
+		}
+		if r.Found && r.Context != test.context {
+			t.Errorf("%q: expected context %q got %v", test.body, test.context, r.Context)
+		}
+	}
+
+	// the phrase is never found by the default raw matching
+	sc := NewScanner(1, 0, false, "")
+	if r := sc.evaluate("http://example.com", kw, []byte("<p>Connect\n with</p><p>friends</p>")); r.Found {
+		t.Error("raw matching should miss the broken up phrase")
+	}
+}
+
+func TestPhraseContext(t *testing.T) {
+	text := strings.Repeat("é", 30) + " sign up " + strings.Repeat("ü", 30)
+	loc := []int{strings.Index(text, "sign"), strings.Index(text, "up") + 2}
+	c := phraseContext(text, loc)
+	if !utf8.ValidString(c) || !strings.Contains(c, "sign up") {
+		t.Errorf("expected whole runes around the match, got %q", c)
+	}
+}
@@ -0,0 +1,64 @@
+package search
+
+import (
+	"crypto/tls"
+	"net/http"
+	"strings"
+)
+
+// hostTLSTransport sends https requests to insecure hosts through a transport that skips certificate checks, every
+// other request, redirects included, goes through the secure one
+type hostTLSTransport struct {
+	secure   http.RoundTripper
+	insecure http.RoundTripper
+	hosts    func() []string
+}
+
+func (t *hostTLSTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme == "https" && t.insecureHost(req.URL.Hostname()) {
+		return t.insecure.RoundTrip(req)
+	}
+	return t.secure.RoundTrip(req)
+}
+
+func (t *hostTLSTransport) insecureHost(host string) bool {
+	for _, h := range t.hosts() {
+		if strings.EqualFold(h, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// client returns the client requests are sent with. When InsecureHosts is set it is a copy of Client whose transport
+// routes those hosts to a clone of the transport with verification turned off, the clone is made on first use so
+// transport changes after that don't reach insecure hosts. Only the default *http.Transport can be cloned, any
+// other transport ignores InsecureHosts
+func (sc *Scanner) client() *http.Client {
+	if len(sc.InsecureHosts) == 0 {
+		return sc.Client
+	}
+
+	sc.tlsOnce.Do(func() {
+		t, ok := sc.Client.Transport.(*http.Transport)
+		if !ok {
+			sc.tlsClient = sc.Client
+			return
+		}
+
+		insecure := t.Clone()
+		if insecure.TLSClientConfig == nil {
+			insecure.TLSClientConfig = &tls.Config{}
+		}
+		insecure.TLSClientConfig.InsecureSkipVerify = true
+
+		c := *sc.Client
+		c.Transport = &hostTLSTransport{
+			secure:   t,
+			insecure: insecure,
+			hosts:    func() []string { return sc.InsecureHosts },
+		}
+		sc.tlsClient = &c
+	})
+	return sc.tlsClient
+}
@@ -0,0 +1,47 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestInsecureHosts(t *testing.T) {
+	var ts *httptest.Server
+	ts = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, strings.Replace(ts.URL, "127.0.0.1", "localhost", 1)+"/", http.StatusFound)
+			return
+		}
+		fmt.Fprint(w, "<p>sign up</p>")
+	}))
+	defer ts.Close()
+	// localhost reaches the same self signed server but isn't in the list
+	localhost := strings.Replace(ts.URL, "127.0.0.1", "localhost", 1)
+
+	sc := NewScanner(1, 0, false, "")
+	sc.InsecureHosts = []string{"127.0.0.1"}
+
+	body, _, err := sc.fetch(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatalf("expected the allowed host to skip verification, got %v", err)
+	}
+	if string(body) != "<p>sign up</p>" {
+		t.Errorf("unexpected body %q", body)
+	}
+
+	if _, _, err := sc.fetch(context.Background(), localhost); err == nil {
+		t.Error("expected a host missing from the list to be verified and fail")
+	}
+	if _, _, err := sc.fetch(context.Background(), ts.URL+"/redirect"); err == nil {
+		t.Error("expected a redirect off the allowed host to be verified and fail")
+	}
+
+	strict := NewScanner(1, 0, false, "")
+	if _, _, err := strict.fetch(context.Background(), ts.URL); err == nil {
+		t.Error("expected verification without InsecureHosts")
+	}
+}
@@ -0,0 +1,70 @@
+package search
+
+import (
+	"io"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// UnicodeForm is a unicode normalization form applied to the page and the keyword before matching
+type UnicodeForm int
+
+const (
+	// UnicodeAsIs matches the bytes as they are
+	UnicodeAsIs UnicodeForm = iota
+	// UnicodeNFC composes characters so é written as e plus a combining accent matches a single é
+	UnicodeNFC
+	// UnicodeNFKC is UnicodeNFC that also folds compatibility characters, e.g. the ﬁ ligature matches fi
+	UnicodeNFKC
+)
+
+func (f UnicodeForm) form() norm.Form {
+	if f == UnicodeNFKC {
+		return norm.NFKC
+	}
+	return norm.NFC
+}
+
+// normalize returns the body in the form, a body already in it is returned as is
+func (f UnicodeForm) normalize(body []byte) []byte {
+	if f == UnicodeAsIs {
+		return body
+	}
+	return f.form().Bytes(body)
+}
+
+// reader normalizes r as it is read
+func (f UnicodeForm) reader(r io.Reader) io.Reader {
+	if f == UnicodeAsIs {
+		return r
+	}
+	return f.form().Reader(r)
+}
+
+// normalized is the keyword compiled in the form, it is compiled once per form. The raw keyword saved on results
+// stays the one passed in
+func (kw *Keyword) normalized(f UnicodeForm) *Keyword {
+	if f == UnicodeAsIs {
+		return kw
+	}
+
+	kw.mxt.Lock()
+	defer kw.mxt.Unlock()
+	if n, ok := kw.forms[f]; ok {
+		return n
+	}
+
+	n, err := NewKeyword(f.form().String(kw.raw), kw.opts)
+	if err != nil {
+		// normalizing only swaps characters for their equivalents so a valid pattern stays valid, keep the
+		// original just in case
+		n = kw
+	} else {
+		n.raw = kw.raw
+	}
+	if kw.forms == nil {
+		kw.forms = make(map[UnicodeForm]*Keyword)
+	}
+	kw.forms[f] = n
+	return n
+}
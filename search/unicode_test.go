@@ -0,0 +1,54 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestUnicodeForm(t *testing.T) {
+	composed, decomposed := "caf\u00e9", "cafe\u0301"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/decomposed":
+			fmt.Fprintf(w, "<p>the best %s in town</p>", decomposed)
+		case "/ligature":
+			fmt.Fprint(w, "<p>\ufb01nd us</p>")
+		default:
+			fmt.Fprintf(w, "<p>the best %s in town</p>", composed)
+		}
+	}))
+	defer ts.Close()
+
+	for _, test := range []struct {
+		form    UnicodeForm
+		path    string
+		keyword string
+		found   bool
+	}{
+		{UnicodeAsIs, "/decomposed", composed, false},
+		{UnicodeAsIs, "/composed", decomposed, false},
+		{UnicodeNFC, "/decomposed", composed, true},
+		{UnicodeNFC, "/composed", decomposed, true},
+		{UnicodeNFC, "/ligature", "find", false},
+		{UnicodeNFKC, "/ligature", "find", true},
+	} {
+		for _, stop := range []bool{false, true} {
+			sc := NewScanner(1, 0, false, "")
+			sc.UnicodeForm = test.form
+			sc.StopOnFirstMatch = stop
+			r, err := sc.Match(context.Background(), ts.URL+test.path, test.keyword)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.Found != test.found {
+				t.Errorf("form %d %s %q: expected found %v, StopOnFirstMatch %v", test.form, test.path, test.keyword, test.found, stop)
+			}
+			if r.Keyword != test.keyword {
+				t.Errorf("expected the keyword as it was passed in, got %q", r.Keyword)
+			}
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	log "github.com/marcsantiago/logger"
+	"github.com/marcsantiago/search_keyword/search"
+)
+
+// searchRequest is the body of POST /search
+type searchRequest struct {
+	URL     string `json:"url"`
+	Keyword string `json:"keyword"`
+	Depth   int    `json:"depth"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// newSearchHandler serves POST /search. Every request gets its own scanner made by sc.Share, so results never mix
+// between requests while the concurrency limit, HostRate pacing and robots.txt rules hold across all of them. -dedup
+// only skips urls seen again within a request, sharing it would leave a repeated request with no results. settings,
+// when set, is applied to each of those scanners, it mustn't touch the client they share, and a request may ask for
+// a depth of at most maxDepth
+func newSearchHandler(sc *search.Scanner, settings func(*search.Scanner), maxDepth int,
+	timeout time.Duration) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/search", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeJSON(w, http.StatusMethodNotAllowed, errorResponse{"only POST is supported"})
+			return
+		}
+
+		var req searchRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{"invalid json: " + err.Error()})
+			return
+		}
+		req.Keyword = search.SanitizeKeyword(req.Keyword)
+		if req.URL == "" || req.Keyword == "" || req.Depth < 0 {
+			writeJSON(w, http.StatusBadRequest, errorResponse{"url and keyword are required and depth can't be negative"})
+			return
+		}
+		if req.Depth > maxDepth {
+			writeJSON(w, http.StatusBadRequest, errorResponse{fmt.Sprintf("depth can be at most %d", maxDepth)})
+			return
+		}
+		if _, err := search.CompilePattern(req.Keyword, search.KeywordOptions{}); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{"invalid keyword: " + err.Error()})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		rs := sc.Share(req.Keyword)
+		if settings != nil {
+			settings(rs)
+		}
+		err := rs.Crawl(ctx, []string{req.URL}, req.Keyword, req.Depth)
+		if err != nil && len(rs.Results) == 0 {
+			writeJSON(w, http.StatusBadGateway, errorResponse{err.Error()})
+			return
+		}
+
+		results := rs.Results
+		if results == nil {
+			results = search.Results{}
+		}
+		writeJSON(w, http.StatusOK, results)
+	})
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Error(logKey, "couldn't write response", "error", err)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/marcsantiago/search_keyword/search"
+)
+
+func TestSearchHandler(t *testing.T) {
+	var site *httptest.Server
+	agents := make(chan string, 10)
+	site = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case agents <- r.UserAgent():
+		default:
+		}
+		if r.URL.Path == "/child" {
+			fmt.Fprint(w, "<p>sign up</p>")
+			return
+		}
+		fmt.Fprintf(w, `<p>welcome</p><a href="%s/child">child</a>`, site.URL)
+	}))
+	defer site.Close()
+
+	var configured int32
+	settings := func(sc *search.Scanner) {
+		atomic.AddInt32(&configured, 1)
+		sc.UserAgent = "serve-test"
+	}
+	api := httptest.NewServer(newSearchHandler(search.NewScanner(2, 0, false, ""), settings, 2, 5*time.Second))
+	defer api.Close()
+
+	post := func(body string) (*http.Response, search.Results) {
+		res, err := http.Post(api.URL+"/search", "application/json", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+
+		var results search.Results
+		if res.StatusCode == http.StatusOK {
+			if err := json.NewDecoder(res.Body).Decode(&results); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return res, results
+	}
+
+	res, results := post(fmt.Sprintf(`{"url": %q, "keyword": "sign up", "depth": 1}`, site.URL))
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 got %d", res.StatusCode)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected the page and its child, got %+v", results)
+	}
+	if urls := results.MatchingURLs(); len(urls) != 1 || urls[0] != site.URL+"/child" {
+		t.Errorf("expected the child to match, got %v", urls)
+	}
+
+	if configured := atomic.LoadInt32(&configured); configured != 1 {
+		t.Errorf("expected the settings to be applied to the request's scanner, applied %d times", configured)
+	}
+	if agent := <-agents; agent != "serve-test" {
+		t.Errorf("expected the pages to be fetched with the configured user agent, got %q", agent)
+	}
+
+	// requests running at once share the client and the host state
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := http.Post(api.URL+"/search", "application/json",
+				bytes.NewBufferString(fmt.Sprintf(`{"url": %q, "keyword": "sign up", "depth": 1}`, site.URL)))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer res.Body.Close()
+			var results search.Results
+			if err := json.NewDecoder(res.Body).Decode(&results); err != nil || len(results) != 2 {
+				t.Errorf("expected the page and its child from a concurrent request, got %+v %v", results, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if res, _ := post(fmt.Sprintf(`{"url": %q, "keyword": "sign (up"}`, site.URL)); res.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected an invalid keyword to be a bad request, got %d", res.StatusCode)
+	}
+	if res, _ := post(fmt.Sprintf(`{"url": %q, "keyword": "sign up", "depth": 3}`, site.URL)); res.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected a depth over the limit to be a bad request, got %d", res.StatusCode)
+	}
+	if res, _ := post(`{"url": "", "keyword": "sign up"}`); res.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected a missing url to be a bad request, got %d", res.StatusCode)
+	}
+	if res, _ := post(`not json`); res.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected invalid json to be a bad request, got %d", res.StatusCode)
+	}
+
+	get, err := http.Get(api.URL + "/search")
+	if err != nil {
+		t.Fatal(err)
+	}
+	get.Body.Close()
+	if get.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("expected GET to be refused, got %d", get.StatusCode)
+	}
+}